@@ -0,0 +1,381 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// CacheStore holds cached responses by key. Implementations must be safe for concurrent use.
+type CacheStore interface {
+	// Get returns the value stored for key, or false if there is none
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores a value for key
+	Set(ctx context.Context, key string, value []byte) error
+
+	// Delete removes the value stored for key, if any
+	Delete(ctx context.Context, key string) error
+}
+
+// Cache serves repeated requests from a CacheStore instead of the provider.
+// Wrap each provider with the cache before registering it with a client:
+//
+//	cache := ai.NewCache(ai.NewMemoryCache(1000), ai.WithCacheTTL(24*time.Hour))
+//	client.RegisterProvider(ai.ProviderOpenAI, cache.Wrap(openai.New()))
+//
+// Requests are keyed on a hash of the provider, model, messages, tools and the other
+// settings that affect the response. Only successful responses are stored: an invalid
+// structured response is sent to the provider again, while the repair attempt that
+// followed it is served from the cache. Failures to read or write the store are
+// treated as cache misses. Results served from the cache have Cached set.
+type Cache struct {
+	store CacheStore
+	ttl   time.Duration
+}
+
+// CacheOption is a function that modifies a Cache
+type CacheOption func(*Cache)
+
+// WithCacheTTL sets how long responses are kept. The default is to keep them indefinitely.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// NewCache creates a new cache backed by store
+func NewCache(store CacheStore, options ...CacheOption) *Cache {
+	c := &Cache{store: store}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c
+}
+
+// WithCacheBypass makes the request skip any cache, neither reading nor storing a response
+func WithCacheBypass() Option {
+	return func(c *Config) {
+		c.CacheBypass = true
+	}
+}
+
+// Wrap returns a provider that serves responses from the cache when it can
+func (c *Cache) Wrap(provider LLMProvider) LLMProvider {
+	return &cachedProvider{cache: c, provider: provider}
+}
+
+// cacheEntry is a stored response
+type cacheEntry struct {
+	Result Result `json:"result"`
+
+	// Object is the JSON encoding of the decoded object, for structured responses
+	Object json.RawMessage `json:"object,omitempty"`
+
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// cacheKeyFields are the parts of a request that determine its response
+type cacheKeyFields struct {
+	Kind        string          `json:"kind"`
+	Provider    Provider        `json:"provider"`
+	Model       string          `json:"model"`
+	Messages    []Message       `json:"messages"`
+	MaxTokens   int             `json:"max_tokens"`
+	Temperature float64         `json:"temperature"`
+	Tools       []Tool          `json:"tools,omitempty"`
+	ObjectMode  ObjectMode      `json:"object_mode,omitempty"`
+	Type        string          `json:"type,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+}
+
+// cacheKey returns the canonical hash of a request. Text requests and generated results
+// are keyed separately, since GetText does not keep the response metadata.
+func cacheKey(kind string, config *Config, target interface{}) (string, error) {
+	fields := cacheKeyFields{
+		Kind:        kind,
+		Provider:    config.Provider,
+		Model:       config.Model,
+		Messages:    config.Messages,
+		MaxTokens:   config.MaxTokens,
+		Temperature: config.Temperature,
+		Tools:       config.Tools,
+	}
+
+	if target != nil {
+		schema, err := SchemaFor(target)
+		if err != nil {
+			return "", err
+		}
+		if fields.Schema, err = json.Marshal(schema); err != nil {
+			return "", err
+		}
+		fields.Type = reflect.TypeOf(target).String()
+		fields.ObjectMode = config.ObjectMode
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cache key: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// get returns the unexpired entry stored for key
+func (c *Cache) get(ctx context.Context, key string) (*cacheEntry, bool) {
+	data, ok, err := c.store.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+
+	if !entry.Expires.IsZero() && time.Now().After(entry.Expires) {
+		c.store.Delete(ctx, key)
+		return nil, false
+	}
+
+	entry.Result.Cached = true
+	return &entry, true
+}
+
+// set stores an entry for key
+func (c *Cache) set(ctx context.Context, key string, entry cacheEntry) {
+	if c.ttl > 0 {
+		entry.Expires = time.Now().Add(c.ttl)
+	}
+	entry.Result.Cached = false
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	c.store.Set(ctx, key, data)
+}
+
+// cachedProvider serves requests to a provider from a Cache
+type cachedProvider struct {
+	cache    *Cache
+	provider LLMProvider
+}
+
+// key returns the cache key for a request, or false if it should not be cached
+func (p *cachedProvider) key(kind string, config *Config, target interface{}) (string, bool) {
+	if config.CacheBypass {
+		return "", false
+	}
+	key, err := cacheKey(kind, config, target)
+	return key, err == nil
+}
+
+// CountTokens counts tokens with the wrapped provider; counts are not cached
+func (p *cachedProvider) CountTokens(ctx context.Context, config *Config) (int, error) {
+	return countTokens(ctx, p.provider, config)
+}
+
+func (p *cachedProvider) GetText(ctx context.Context, config *Config) (string, error) {
+	key, ok := p.key("text", config, nil)
+	if !ok {
+		return p.provider.GetText(ctx, config)
+	}
+
+	if entry, ok := p.cache.get(ctx, key); ok {
+		return entry.Result.Text, nil
+	}
+
+	text, err := p.provider.GetText(ctx, config)
+	if err != nil {
+		return "", err
+	}
+
+	p.cache.set(ctx, key, cacheEntry{Result: Result{Text: text}})
+	return text, nil
+}
+
+func (p *cachedProvider) GenerateText(ctx context.Context, config *Config) (*Result, error) {
+	key, ok := p.key("generate", config, nil)
+	if !ok {
+		return generateText(ctx, p.provider, config)
+	}
+
+	if entry, ok := p.cache.get(ctx, key); ok {
+		return &entry.Result, nil
+	}
+
+	result, err := generateText(ctx, p.provider, config)
+	if err != nil {
+		return nil, err
+	}
+
+	p.cache.set(ctx, key, cacheEntry{Result: *result})
+	return result, nil
+}
+
+func (p *cachedProvider) GetObject(ctx context.Context, config *Config, target interface{}) error {
+	_, err := p.GenerateObject(ctx, config, target)
+	return err
+}
+
+func (p *cachedProvider) GenerateObject(ctx context.Context, config *Config, target interface{}) (*Result, error) {
+	key, ok := p.key("object", config, target)
+	if !ok {
+		return getObject(ctx, p.provider, config, target)
+	}
+
+	if entry, ok := p.cache.get(ctx, key); ok {
+		if err := decodeCachedObject(entry.Object, target); err == nil {
+			return &entry.Result, nil
+		}
+	}
+
+	result, err := getObject(ctx, p.provider, config, target)
+	if err != nil {
+		return result, err
+	}
+
+	object, err := json.Marshal(target)
+	if err == nil {
+		p.cache.set(ctx, key, cacheEntry{Result: *result, Object: object})
+	}
+	return result, nil
+}
+
+func (p *cachedProvider) StreamText(ctx context.Context, config *Config) (*Stream, error) {
+	streamer, ok := p.provider.(StreamingProvider)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStreamingNotSupported, config.Provider)
+	}
+
+	// Streams share entries with GenerateText
+	key, ok := p.key("generate", config, nil)
+	if !ok {
+		return streamer.StreamText(ctx, config)
+	}
+
+	if entry, ok := p.cache.get(ctx, key); ok {
+		return replayStream(&entry.Result, entry.Result.Text), nil
+	}
+
+	stream, err := streamer.StreamText(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	stream.OnFinish(func(result *Result, err error) {
+		// Streams closed early have no finish reason
+		if err == nil && result.FinishReason != "" {
+			p.cache.set(ctx, key, cacheEntry{Result: *result})
+		}
+	})
+	return stream, nil
+}
+
+func (p *cachedProvider) StreamObject(ctx context.Context, config *Config, target interface{}) (*Stream, error) {
+	streamer, ok := p.provider.(ObjectStreamer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStreamingNotSupported, config.Provider)
+	}
+
+	// Object streams share entries with GenerateObject
+	key, ok := p.key("object", config, target)
+	if !ok {
+		return streamer.StreamObject(ctx, config, target)
+	}
+
+	if entry, ok := p.cache.get(ctx, key); ok && len(entry.Object) > 0 {
+		return replayStream(&entry.Result, string(entry.Object)), nil
+	}
+
+	stream, err := streamer.StreamObject(ctx, config, target)
+	if err != nil {
+		return nil, err
+	}
+
+	stream.OnFinish(func(result *Result, err error) {
+		if err != nil || result.FinishReason == "" {
+			return
+		}
+
+		// Only store responses that decode into a valid object
+		decoded := reflect.New(reflect.TypeOf(target).Elem()).Interface()
+		if DecodeObject(result.Text, decoded) != nil {
+			return
+		}
+		if object, err := json.Marshal(decoded); err == nil {
+			p.cache.set(ctx, key, cacheEntry{Result: *result, Object: object})
+		}
+	})
+	return stream, nil
+}
+
+// decodeCachedObject decodes a stored object into target, replacing its contents
+func decodeCachedObject(object json.RawMessage, target interface{}) error {
+	if len(object) == 0 {
+		return fmt.Errorf("%w: no object stored", ErrInvalidObject)
+	}
+
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return fmt.Errorf("%w: %T", ErrInvalidObjectTarget, target)
+	}
+
+	decoded := reflect.New(value.Elem().Type())
+	if err := json.Unmarshal(object, decoded.Interface()); err != nil {
+		return err
+	}
+	value.Elem().Set(decoded.Elem())
+	return nil
+}
+
+// replayStream returns a stream that replays a stored result with the given text
+func replayStream(result *Result, text string) *Stream {
+	reader := &replayReader{}
+	reader.deltas = append(reader.deltas, Delta{ID: result.ID, Model: result.Model})
+	if text != "" {
+		reader.deltas = append(reader.deltas, Delta{Text: text})
+	}
+	for i, call := range result.ToolCalls {
+		reader.deltas = append(reader.deltas, Delta{ToolCall: &ToolCallDelta{
+			Index:     i,
+			ID:        call.ID,
+			Name:      call.Name,
+			Arguments: string(call.Arguments),
+		}})
+	}
+	usage := result.Usage
+	reader.deltas = append(reader.deltas, Delta{FinishReason: result.FinishReason, Usage: &usage})
+
+	stream := NewStream(reader)
+	stream.metadata.Cached = true
+	return stream
+}
+
+// replayReader reads deltas from a slice
+type replayReader struct {
+	deltas []Delta
+}
+
+func (r *replayReader) Recv() (Delta, error) {
+	if len(r.deltas) == 0 {
+		return Delta{}, io.EOF
+	}
+	delta := r.deltas[0]
+	r.deltas = r.deltas[1:]
+	return delta, nil
+}
+
+func (r *replayReader) Close() error {
+	return nil
+}
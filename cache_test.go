@@ -0,0 +1,228 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// countingGenerator returns a generator that counts its calls
+func countingGenerator(calls *int) *MockGenerator {
+	return &MockGenerator{
+		GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+			*calls++
+			return &Result{Text: "Hello!", FinishReason: FinishReasonStop, Usage: Usage{InputTokens: 5, OutputTokens: 2}, Model: config.Model}, nil
+		},
+	}
+}
+
+func TestCacheGenerateText(t *testing.T) {
+	var calls int
+	cache := NewCache(NewMemoryCache(10))
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, cache.Wrap(countingGenerator(&calls)))
+
+	messages := WithMessages(UserMessage("Hello"))
+
+	first, err := client.GenerateText(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, err := client.GenerateText(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected 1 provider call, got %d", calls)
+	}
+	if first.Cached || !second.Cached {
+		t.Errorf("Expected only the second result to be cached")
+	}
+	if second.Text != "Hello!" || second.Usage.OutputTokens != 2 || second.Model != "test-model" {
+		t.Errorf("Unexpected cached result: %+v", second)
+	}
+
+	// Any change to the request misses the cache
+	if _, err := client.GenerateText(context.Background(), messages, WithTemperature(0)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := client.GenerateText(context.Background(), WithMessages(UserMessage("Hi"))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 provider calls, got %d", calls)
+	}
+
+	// Bypassed requests go to the provider
+	result, err := client.GenerateText(context.Background(), messages, WithCacheBypass())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if calls != 4 || result.Cached {
+		t.Errorf("Expected the cache to be bypassed")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	var calls int
+	store := NewMemoryCache(10)
+	cache := NewCache(store, WithCacheTTL(time.Millisecond))
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, cache.Wrap(countingGenerator(&calls)))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GenerateText(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected expired entries to be refetched, got %d calls", calls)
+	}
+}
+
+func TestCacheGetObject(t *testing.T) {
+	provider := &MockObjectGenerator{GenerateObjectFunc: objectResponses(`{"name":"Ada"}`, `{"name":"Ada","age":36}`, `{"name":"Ada"}`, `{"name":"Bob","age":1}`)}
+	var calls int
+	generate := provider.GenerateObjectFunc
+	provider.GenerateObjectFunc = func(ctx context.Context, config *Config, target interface{}) (*Result, error) {
+		calls++
+		return generate(ctx, config, target)
+	}
+
+	cache := NewCache(NewMemoryCache(10))
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"), WithRepair(1))
+	client.RegisterProvider(ProviderOpenAI, cache.Wrap(provider))
+
+	type person struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	// The invalid first response is not cached, but its repair is
+	first, err := GenerateObject[person](context.Background(), client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, err := GenerateObject[person](context.Background(), client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("Expected 3 provider calls, got %d", calls)
+	}
+	if first.Object != second.Object || second.Object.Age != 36 || !second.Cached {
+		t.Errorf("Expected the repaired object from the cache, got %+v", second)
+	}
+
+	// Targets of another type are keyed separately
+	var other struct {
+		Name string `json:"name"`
+	}
+	if err := client.GetObject(context.Background(), &other); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if calls != 4 || other.Name != "Bob" {
+		t.Errorf("Expected a provider call for another type, got %d calls and %+v", calls, other)
+	}
+}
+
+func TestCacheStream(t *testing.T) {
+	var streams int
+	provider := &MockStreamer{
+		StreamTextFunc: func(ctx context.Context, config *Config) (*Stream, error) {
+			streams++
+			return NewStream(&sliceReader{deltas: []Delta{
+				{ID: "resp_1", Model: "test-model"},
+				{Text: "Hel"},
+				{Text: "lo!"},
+				{FinishReason: FinishReasonStop, Usage: &Usage{InputTokens: 5, OutputTokens: 2}},
+			}}), nil
+		},
+	}
+
+	cache := NewCache(NewMemoryCache(10))
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, cache.Wrap(provider))
+
+	for i := 0; i < 2; i++ {
+		stream, err := client.StreamText(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for stream.Next() {
+		}
+		result := stream.Result()
+		if result.Text != "Hello!" || result.ID != "resp_1" || result.Usage.OutputTokens != 2 || result.Cached != (i == 1) {
+			t.Errorf("Unexpected result for stream %d: %+v", i, result)
+		}
+	}
+
+	// Streams share entries with GenerateText
+	result, err := client.GenerateText(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if streams != 1 || result.Text != "Hello!" || !result.Cached {
+		t.Errorf("Expected the streamed result from the cache, got %+v after %d streams", result, streams)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCache(2)
+
+	store.Set(ctx, "a", []byte("1"))
+	store.Set(ctx, "b", []byte("2"))
+	store.Get(ctx, "a")
+	store.Set(ctx, "c", []byte("3"))
+
+	// b was the least recently used
+	if _, ok, _ := store.Get(ctx, "b"); ok {
+		t.Errorf("Expected b to be evicted")
+	}
+	if value, ok, _ := store.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Errorf("Expected a to be kept, got %q", value)
+	}
+	if store.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", store.Len())
+	}
+
+	store.Delete(ctx, "a")
+	if _, ok, _ := store.Get(ctx, "a"); ok {
+		t.Errorf("Expected a to be deleted")
+	}
+}
+
+func TestFileCache(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileCache(t.TempDir() + "/cache")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, ok, err := store.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("Expected a miss, got %v, %v", ok, err)
+	}
+
+	if err := store.Set(ctx, "key", []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	value, ok, err := store.Get(ctx, "key")
+	if !ok || err != nil || string(value) != `{"a":1}` {
+		t.Errorf("Expected the stored value, got %q, %v, %v", value, ok, err)
+	}
+
+	if err := store.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := store.Delete(ctx, "key"); err != nil {
+		t.Errorf("Expected deleting a missing key to succeed, got %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, "key"); ok {
+		t.Errorf("Expected key to be deleted")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 )
 
 var (
@@ -12,12 +13,16 @@ var (
 
 	// ErrModelNotSpecified is returned when no model is specified
 	ErrModelNotSpecified = errors.New("model not specified")
+
+	// ErrToolsNotSupported is returned when tools are requested from a provider that cannot call them
+	ErrToolsNotSupported = errors.New("provider does not support tool calling")
 )
 
 // Client is the main entry point for the go-ai-sdk
 type Client struct {
-	providers map[Provider]LLMProvider
-	defaults  *Config
+	providers  map[Provider]LLMProvider
+	defaults   *Config
+	middleware []Middleware
 }
 
 // NewClient creates a new client with default configuration
@@ -50,6 +55,20 @@ func (c *Client) mergeConfig(options ...Option) *Config {
 		Model:       c.defaults.Model,
 		MaxTokens:   c.defaults.MaxTokens,
 		Temperature: c.defaults.Temperature,
+
+		MaxSteps:          c.defaults.MaxSteps,
+		ParallelToolCalls: c.defaults.ParallelToolCalls,
+		ObjectMode:        c.defaults.ObjectMode,
+		RepairAttempts:    c.defaults.RepairAttempts,
+		RepairStrategy:    c.defaults.RepairStrategy,
+		Retry:             c.defaults.Retry,
+		RateLimitObserver: c.defaults.RateLimitObserver,
+		CacheBypass:       c.defaults.CacheBypass,
+		Models:            c.defaults.Models,
+		RejectDeprecated:  c.defaults.RejectDeprecated,
+		Logger:            c.defaults.Logger,
+		LogLevel:          c.defaults.LogLevel,
+		LogContent:        c.defaults.LogContent,
 	}
 
 	// Copy messages (if any)
@@ -58,6 +77,26 @@ func (c *Client) mergeConfig(options ...Option) *Config {
 		copy(config.Messages, c.defaults.Messages)
 	}
 
+	// Copy tools (if any)
+	if len(c.defaults.Tools) > 0 {
+		config.Tools = make([]Tool, len(c.defaults.Tools))
+		copy(config.Tools, c.defaults.Tools)
+	}
+
+	// Copy cost tags (if any)
+	if len(c.defaults.CostTags) > 0 {
+		config.CostTags = make([]string, len(c.defaults.CostTags))
+		copy(config.CostTags, c.defaults.CostTags)
+	}
+
+	// Copy tool handlers (if any)
+	if len(c.defaults.ToolHandlers) > 0 {
+		config.ToolHandlers = make(map[string]ToolHandler, len(c.defaults.ToolHandlers))
+		for name, handler := range c.defaults.ToolHandlers {
+			config.ToolHandlers[name] = handler
+		}
+	}
+
 	// Apply the options
 	for _, opt := range options {
 		opt(config)
@@ -66,34 +105,149 @@ func (c *Client) mergeConfig(options ...Option) *Config {
 	return config
 }
 
+// provider validates the config and returns the provider it selects
+func (c *Client) provider(config *Config) (LLMProvider, error) {
+	if config.Model == "" {
+		return nil, ErrModelNotSpecified
+	}
+
+	provider, ok := c.providers[config.Provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotSupported, config.Provider)
+	}
+
+	return provider, nil
+}
+
 // GetText gets a text response from the specified provider
 func (c *Client) GetText(ctx context.Context, options ...Option) (string, error) {
 	config := c.mergeConfig(options...)
 
-	if config.Model == "" {
-		return "", ErrModelNotSpecified
+	if _, err := c.provider(config); err != nil {
+		return "", err
 	}
 
-	provider, ok := c.providers[config.Provider]
-	if !ok {
-		return "", fmt.Errorf("%w: %s", ErrProviderNotSupported, config.Provider)
+	resp, err := c.handle(ctx, &Request{Kind: RequestText, Config: config})
+	if err != nil {
+		return "", err
 	}
 
-	return provider.GetText(ctx, config)
+	return resp.Result.Text, nil
 }
 
-// GetObject gets a structured response from the specified provider
+// GenerateText gets a full result, including any tool calls, from the specified provider.
+// Providers that do not implement Generator fall back to GetText when no tools are requested.
+func (c *Client) GenerateText(ctx context.Context, options ...Option) (*Result, error) {
+	config := c.mergeConfig(options...)
+
+	if _, err := c.provider(config); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.handle(ctx, &Request{Kind: RequestGenerate, Config: config})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Result, nil
+}
+
+// generateText gets a full result from provider, falling back to GetText
+// for providers that do not implement Generator
+func generateText(ctx context.Context, provider LLMProvider, config *Config) (*Result, error) {
+	if generator, ok := provider.(Generator); ok {
+		return generator.GenerateText(ctx, config)
+	}
+
+	if len(config.Tools) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrToolsNotSupported, config.Provider)
+	}
+
+	text, err := provider.GetText(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Text: text}, nil
+}
+
+// GetObject gets a structured response from the specified provider.
+// If the response is invalid and repair is enabled with WithRepair, the model is
+// re-prompted with the error; once all attempts fail an *ObjectRepairError is returned.
 func (c *Client) GetObject(ctx context.Context, target interface{}, options ...Option) error {
+	_, err := c.generateObject(ctx, target, options...)
+	return err
+}
+
+// generateObject decodes a structured response into target and returns its metadata.
+// Usage is summed over any repair attempts.
+func (c *Client) generateObject(ctx context.Context, target interface{}, options ...Option) (*Result, error) {
 	config := c.mergeConfig(options...)
 
-	if config.Model == "" {
-		return ErrModelNotSpecified
+	if _, err := c.provider(config); err != nil {
+		return nil, err
 	}
 
-	provider, ok := c.providers[config.Provider]
-	if !ok {
-		return fmt.Errorf("%w: %s", ErrProviderNotSupported, config.Provider)
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return nil, fmt.Errorf("%w: %T", ErrInvalidObjectTarget, target)
+	}
+
+	strategy := config.RepairStrategy
+	if strategy == nil {
+		strategy = DefaultRepairStrategy
 	}
 
-	return provider.GetObject(ctx, config, target)
+	var attempts []ObjectAttempt
+	var usage Usage
+	var cost float64
+	attemptConfig := *config
+
+	for {
+		var result *Result
+		resp, err := c.handle(ctx, &Request{Kind: RequestObject, Config: &attemptConfig, Target: target})
+		if resp != nil {
+			result = resp.Result
+		}
+		if result != nil {
+			usage.InputTokens += result.Usage.InputTokens
+			usage.OutputTokens += result.Usage.OutputTokens
+			usage.CachedInputTokens += result.Usage.CachedInputTokens
+			result.Usage = usage
+
+			cost += result.Cost
+			result.Cost = cost
+		}
+
+		var invalid *InvalidObjectError
+		if err == nil || config.RepairAttempts <= 0 || !errors.As(err, &invalid) {
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+
+		attempts = append(attempts, ObjectAttempt{Raw: invalid.Raw, Err: invalid.Err})
+		if len(attempts) > config.RepairAttempts {
+			return nil, &ObjectRepairError{Attempts: attempts}
+		}
+
+		// Clear anything decoded from the invalid response before retrying
+		value.Elem().Set(reflect.Zero(value.Elem().Type()))
+
+		attemptConfig.Messages = strategy(attemptConfig.Messages, invalid.Raw, invalid.Err)
+	}
+}
+
+// getObject decodes a structured response into target, using the provider's
+// ObjectGenerator implementation when it has one to also return the response metadata
+func getObject(ctx context.Context, provider LLMProvider, config *Config, target interface{}) (*Result, error) {
+	if generator, ok := provider.(ObjectGenerator); ok {
+		return generator.GenerateObject(ctx, config, target)
+	}
+
+	if err := provider.GetObject(ctx, config, target); err != nil {
+		return nil, err
+	}
+	return &Result{}, nil
 }
@@ -3,6 +3,7 @@ package ai
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -160,3 +161,154 @@ func TestGetObject(t *testing.T) {
 		t.Errorf("Expected 'Hello, world!', got %s", resp.Message)
 	}
 }
+
+// MockGenerator implements LLMProvider and Generator for testing
+type MockGenerator struct {
+	MockProvider
+	GenerateTextFunc func(ctx context.Context, config *Config) (*Result, error)
+}
+
+func (m *MockGenerator) GenerateText(ctx context.Context, config *Config) (*Result, error) {
+	return m.GenerateTextFunc(ctx, config)
+}
+
+func TestGenerateText(t *testing.T) {
+	weatherTool := Tool{
+		Name:        "get_weather",
+		Description: "Get the weather for a city",
+		Parameters:  []byte(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+	}
+
+	mockGenerator := &MockGenerator{
+		GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+			if len(config.Tools) != 1 || config.Tools[0].Name != "get_weather" {
+				return nil, errors.New("unexpected tools")
+			}
+			return &Result{
+				ToolCalls: []ToolCall{
+					{ID: "call_1", Name: "get_weather", Arguments: []byte(`{"city":"Paris"}`)},
+				},
+			}, nil
+		},
+	}
+
+	client := NewClient(WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, mockGenerator)
+
+	result, err := client.GenerateText(context.Background(),
+		WithProvider(ProviderOpenAI),
+		WithTools(weatherTool),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.ToolCalls) != 1 || result.ToolCalls[0].Name != "get_weather" {
+		t.Errorf("Expected a get_weather tool call, got %+v", result.ToolCalls)
+	}
+
+	msg := result.Message()
+	if msg.Role != RoleAssistant || len(msg.ToolCalls) != 1 {
+		t.Errorf("Expected assistant message with one tool call, got %+v", msg)
+	}
+
+	// Providers without Generator fall back to GetText when no tools are requested
+	mockProvider := &MockProvider{
+		GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+			return "Hello, world!", nil
+		},
+	}
+	client.RegisterProvider(ProviderAnthropic, mockProvider)
+
+	result, err = client.GenerateText(context.Background(), WithProvider(ProviderAnthropic))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Text != "Hello, world!" {
+		t.Errorf("Expected 'Hello, world!', got %s", result.Text)
+	}
+
+	// ...but cannot handle tools
+	_, err = client.GenerateText(context.Background(),
+		WithProvider(ProviderAnthropic),
+		WithTools(weatherTool),
+	)
+	if !errors.Is(err, ErrToolsNotSupported) {
+		t.Errorf("Expected ErrToolsNotSupported, got %v", err)
+	}
+}
+
+func TestGetObjectRepair(t *testing.T) {
+	type Answer struct {
+		Value int `json:"value"`
+	}
+
+	newProvider := func(responses ...string) (*MockProvider, *[][]Message) {
+		var calls [][]Message
+		return &MockProvider{
+			GetObjectFunc: func(ctx context.Context, config *Config, target interface{}) error {
+				calls = append(calls, config.Messages)
+				return DecodeObject(responses[len(calls)-1], target)
+			},
+		}, &calls
+	}
+
+	// Succeeds on the second attempt
+	mockProvider, calls := newProvider(`{"value":"forty-two"}`, `{"value":42}`)
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, mockProvider)
+
+	var answer Answer
+	err := client.GetObject(context.Background(), &answer,
+		WithMessages(UserMessage("What is the answer?")),
+		WithRepair(2),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if answer.Value != 42 {
+		t.Errorf("Expected 42, got %d", answer.Value)
+	}
+
+	if len(*calls) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(*calls))
+	}
+	repair := (*calls)[1]
+	if len(repair) != 3 || repair[1].Role != RoleAssistant || repair[1].Content != `{"value":"forty-two"}` {
+		t.Errorf("Expected previous response in repair messages, got %+v", repair)
+	}
+	if repair[2].Role != RoleUser || !strings.Contains(repair[2].Content, "$.value: expected integer, got string") {
+		t.Errorf("Expected error in repair prompt, got %+v", repair[2])
+	}
+
+	// Fails after all attempts
+	mockProvider, calls = newProvider(`not json`, `{"value":"x"}`, `{}`)
+	client.RegisterProvider(ProviderOpenAI, mockProvider)
+
+	err = client.GetObject(context.Background(), &answer,
+		WithMessages(UserMessage("What is the answer?")),
+		WithRepair(2),
+	)
+
+	var repairErr *ObjectRepairError
+	if !errors.As(err, &repairErr) {
+		t.Fatalf("Expected ObjectRepairError, got %v", err)
+	}
+	if !errors.Is(err, ErrInvalidObject) {
+		t.Errorf("Expected error to match ErrInvalidObject")
+	}
+	if len(repairErr.Attempts) != 3 || repairErr.Attempts[0].Raw != "not json" || repairErr.Attempts[2].Raw != "{}" {
+		t.Errorf("Unexpected attempts: %+v", repairErr.Attempts)
+	}
+
+	// Without repair the error is returned as is
+	mockProvider, calls = newProvider(`not json`)
+	client.RegisterProvider(ProviderOpenAI, mockProvider)
+
+	err = client.GetObject(context.Background(), &answer, WithMessages(UserMessage("What is the answer?")))
+
+	var invalid *InvalidObjectError
+	if !errors.As(err, &invalid) || len(*calls) != 1 {
+		t.Errorf("Expected a single InvalidObjectError, got %v after %d calls", err, len(*calls))
+	}
+}
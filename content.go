@@ -0,0 +1,85 @@
+package ai
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+)
+
+// ErrUnsupportedContent is returned when a provider cannot send a message's content parts
+var ErrUnsupportedContent = errors.New("unsupported message content")
+
+// PartType identifies the kind of content held by a Part
+type PartType string
+
+const (
+	PartTypeText     PartType = "text"
+	PartTypeImage    PartType = "image"
+	PartTypeDocument PartType = "document"
+)
+
+// Part is a piece of multimodal message content. Images and documents hold
+// either base64-encoded Data with its MediaType, or a URL.
+type Part struct {
+	Type PartType `json:"type"`
+	Text string   `json:"text,omitempty"`
+
+	// MediaType is the MIME type of the data, such as image/png or application/pdf
+	MediaType string `json:"media_type,omitempty"`
+
+	// Data is the base64-encoded content of an image or document
+	Data string `json:"data,omitempty"`
+
+	// URL references an image or document instead of embedding its data
+	URL string `json:"url,omitempty"`
+
+	// Name is an optional file name or title for a document
+	Name string `json:"name,omitempty"`
+}
+
+// TextPart creates a text content part
+func TextPart(text string) Part {
+	return Part{Type: PartTypeText, Text: text}
+}
+
+// ImagePart creates an image content part from raw image bytes.
+// If mediaType is empty it is detected from the data.
+func ImagePart(data []byte, mediaType string) Part {
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data)
+	}
+	return ImageBase64Part(base64.StdEncoding.EncodeToString(data), mediaType)
+}
+
+// ImageBase64Part creates an image content part from base64-encoded image data
+func ImageBase64Part(data string, mediaType string) Part {
+	return Part{Type: PartTypeImage, Data: data, MediaType: mediaType}
+}
+
+// ImageURLPart creates an image content part referencing an image by URL
+func ImageURLPart(url string) Part {
+	return Part{Type: PartTypeImage, URL: url}
+}
+
+// DocumentPart creates a document content part, such as a PDF, from raw bytes
+func DocumentPart(data []byte, mediaType string, name string) Part {
+	return Part{
+		Type:      PartTypeDocument,
+		Data:      base64.StdEncoding.EncodeToString(data),
+		MediaType: mediaType,
+		Name:      name,
+	}
+}
+
+// DocumentURLPart creates a document content part referencing a document by URL
+func DocumentURLPart(url string) Part {
+	return Part{Type: PartTypeDocument, URL: url}
+}
+
+// UserMessageParts creates a new user message made up of content parts
+func UserMessageParts(parts ...Part) Message {
+	return Message{
+		Role:  RoleUser,
+		Parts: parts,
+	}
+}
@@ -0,0 +1,42 @@
+package ai
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestContentParts(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	msg := UserMessageParts(
+		TextPart("What is in this image?"),
+		ImagePart(png, ""),
+		ImageURLPart("https://example.com/cat.jpg"),
+		DocumentPart([]byte("%PDF-1.7"), "application/pdf", "report.pdf"),
+	)
+
+	if msg.Role != RoleUser || len(msg.Parts) != 4 {
+		t.Fatalf("Unexpected message: %+v", msg)
+	}
+
+	if msg.Parts[0].Type != PartTypeText || msg.Parts[0].Text != "What is in this image?" {
+		t.Errorf("Unexpected text part: %+v", msg.Parts[0])
+	}
+
+	image := msg.Parts[1]
+	if image.Type != PartTypeImage || image.MediaType != "image/png" {
+		t.Errorf("Expected detected image/png media type, got %+v", image)
+	}
+	if image.Data != base64.StdEncoding.EncodeToString(png) {
+		t.Errorf("Expected base64-encoded image data, got %s", image.Data)
+	}
+
+	if msg.Parts[2].URL != "https://example.com/cat.jpg" || msg.Parts[2].Data != "" {
+		t.Errorf("Unexpected image URL part: %+v", msg.Parts[2])
+	}
+
+	document := msg.Parts[3]
+	if document.Type != PartTypeDocument || document.MediaType != "application/pdf" || document.Name != "report.pdf" {
+		t.Errorf("Unexpected document part: %+v", document)
+	}
+}
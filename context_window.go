@@ -0,0 +1,277 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TruncationStrategy shortens the messages of a request that does not fit in the context window.
+// fits reports whether a list of messages fits; the strategy returns the messages to send instead.
+type TruncationStrategy func(ctx context.Context, messages []Message, fits func([]Message) (bool, error)) ([]Message, error)
+
+// contextWindow holds the settings of the ContextWindow middleware
+type contextWindow struct {
+	limit      int
+	reserved   int
+	count      func(ctx context.Context, config *Config) (int, error)
+	strategy   TruncationStrategy
+	onTruncate func(ctx context.Context, before, after []Message)
+}
+
+// ContextWindowOption is a function that configures the ContextWindow middleware
+type ContextWindowOption func(*contextWindow)
+
+// WithReservedOutput sets the tokens kept free for the response (defaults to the request's MaxTokens)
+func WithReservedOutput(tokens int) ContextWindowOption {
+	return func(w *contextWindow) {
+		w.reserved = tokens
+	}
+}
+
+// WithTruncation sets how messages are shortened to fit (defaults to DropOldest())
+func WithTruncation(strategy TruncationStrategy) ContextWindowOption {
+	return func(w *contextWindow) {
+		w.strategy = strategy
+	}
+}
+
+// WithContextTokenCounter sets how the input tokens of a request are counted (defaults to
+// EstimateTokens). Pass a provider's CountTokens method to count them exactly.
+func WithContextTokenCounter(count func(ctx context.Context, config *Config) (int, error)) ContextWindowOption {
+	return func(w *contextWindow) {
+		w.count = count
+	}
+}
+
+// WithTruncationHook sets a function called with the messages before and after each truncation
+func WithTruncationHook(hook func(ctx context.Context, before, after []Message)) ContextWindowOption {
+	return func(w *contextWindow) {
+		w.onTruncate = hook
+	}
+}
+
+// ContextWindow returns middleware that keeps each request within a context window of limit
+// tokens, leaving room for the response. Requests that do not fit are shortened with the
+// truncation strategy; system messages and the latest message are always kept. Requests that
+// cannot be shortened enough fail with an error wrapping ErrContextLengthExceeded.
+//
+// A limit of zero uses the context window of the requested model from the config's model
+// registry; requests to models it does not know are sent unchanged.
+func ContextWindow(limit int, options ...ContextWindowOption) Middleware {
+	w := &contextWindow{
+		limit:    limit,
+		reserved: -1,
+		count: func(ctx context.Context, config *Config) (int, error) {
+			return EstimateTokens(config), nil
+		},
+		strategy: DropOldest(),
+	}
+
+	for _, opt := range options {
+		opt(w)
+	}
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			config := *req.Config
+
+			limit := w.limit
+			if limit <= 0 {
+				info, ok := modelInfo(&config)
+				if !ok || info.ContextWindow <= 0 {
+					return next(ctx, req)
+				}
+				limit = info.ContextWindow
+			}
+
+			reserved := w.reserved
+			if reserved < 0 {
+				reserved = config.MaxTokens
+			}
+
+			fits := func(messages []Message) (bool, error) {
+				config.Messages = messages
+				tokens, err := w.count(ctx, &config)
+				if err != nil {
+					return false, fmt.Errorf("failed to count tokens: %w", err)
+				}
+				return tokens+reserved <= limit, nil
+			}
+
+			ok, err := fits(req.Config.Messages)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return next(ctx, req)
+			}
+
+			messages, err := w.strategy(ctx, req.Config.Messages, fits)
+			if err != nil {
+				return nil, err
+			}
+			if w.onTruncate != nil {
+				w.onTruncate(ctx, req.Config.Messages, messages)
+			}
+
+			config.Messages = messages
+			truncated := *req
+			truncated.Config = &config
+			return next(ctx, &truncated)
+		}
+	}
+}
+
+// historyGroups groups the indexes of the messages that may be dropped into turns: each user
+// message with the assistant messages and tool results that follow it. System messages are never
+// dropped, and dropping whole turns keeps tool calls with their results, since providers reject a
+// tool result without its call, and keeps the history starting with a user message.
+func historyGroups(messages []Message) [][]int {
+	var groups [][]int
+	for i, msg := range messages {
+		switch {
+		case msg.Role == RoleSystem:
+			continue
+		case msg.Role != RoleUser && len(groups) > 0:
+			groups[len(groups)-1] = append(groups[len(groups)-1], i)
+		default:
+			groups = append(groups, []int{i})
+		}
+	}
+	return groups
+}
+
+// dropGroups splits messages into those kept and the first n groups, which are dropped
+func dropGroups(messages []Message, groups [][]int, n int) (kept, dropped []Message) {
+	drop := make(map[int]bool)
+	for _, group := range groups[:n] {
+		for _, i := range group {
+			drop[i] = true
+		}
+	}
+
+	for i, msg := range messages {
+		if drop[i] {
+			dropped = append(dropped, msg)
+		} else {
+			kept = append(kept, msg)
+		}
+	}
+	return kept, dropped
+}
+
+// dropOldest returns the smallest number of the oldest groups that must be dropped for the
+// messages to fit, keeping at least the latest turn
+func dropOldest(messages []Message, groups [][]int, from int, fits func([]Message) (bool, error)) (int, error) {
+	for n := from; n < len(groups); n++ {
+		kept, _ := dropGroups(messages, groups, n)
+		ok, err := fits(kept)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: the latest message does not fit in the context window", ErrContextLengthExceeded)
+}
+
+// DropOldest returns a strategy that drops the oldest turns, each a user message and the
+// replies to it, until the rest fit. System messages are kept.
+func DropOldest() TruncationStrategy {
+	return func(ctx context.Context, messages []Message, fits func([]Message) (bool, error)) ([]Message, error) {
+		groups := historyGroups(messages)
+
+		n, err := dropOldest(messages, groups, 0, fits)
+		if err != nil {
+			return nil, err
+		}
+
+		kept, _ := dropGroups(messages, groups, n)
+		return kept, nil
+	}
+}
+
+// Summarize returns a strategy that replaces the oldest messages with a summary of them, written
+// by summarize. The summary is sent as a system message after the other leading system messages.
+// If the summary does not leave enough room, more messages are dropped and summarized again.
+func Summarize(summarize func(ctx context.Context, dropped []Message) (string, error)) TruncationStrategy {
+	return func(ctx context.Context, messages []Message, fits func([]Message) (bool, error)) ([]Message, error) {
+		groups := historyGroups(messages)
+
+		n, err := dropOldest(messages, groups, 0, fits)
+		if err != nil {
+			return nil, err
+		}
+
+		for ; n < len(groups); n++ {
+			kept, dropped := dropGroups(messages, groups, n)
+
+			summary, err := summarize(ctx, dropped)
+			if err != nil {
+				return nil, fmt.Errorf("failed to summarize messages: %w", err)
+			}
+
+			summarized := insertSummary(kept, summary)
+			ok, err := fits(summarized)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return summarized, nil
+			}
+		}
+
+		return nil, fmt.Errorf("%w: the latest message does not fit in the context window with a summary", ErrContextLengthExceeded)
+	}
+}
+
+// insertSummary inserts a summary of earlier messages after the leading system messages
+func insertSummary(messages []Message, summary string) []Message {
+	i := 0
+	for i < len(messages) && messages[i].Role == RoleSystem {
+		i++
+	}
+
+	summarized := make([]Message, 0, len(messages)+1)
+	summarized = append(summarized, messages[:i]...)
+	summarized = append(summarized, SystemMessage("Summary of the earlier conversation:\n"+summary))
+	return append(summarized, messages[i:]...)
+}
+
+// summaryPrompt instructs the model summarizing dropped messages
+const summaryPrompt = "Summarize the following conversation between a user and an assistant. " +
+	"Keep the facts, decisions and open questions needed to continue it. Reply with the summary only."
+
+// SummarizeWith returns a Summarize strategy that asks a model to write the summary, such as a
+// smaller model than the one being called. The options select the model and its settings.
+func SummarizeWith(client *Client, options ...Option) TruncationStrategy {
+	return Summarize(func(ctx context.Context, dropped []Message) (string, error) {
+		var transcript strings.Builder
+		for _, msg := range dropped {
+			writeTranscript(&transcript, msg)
+		}
+
+		opts := append(append([]Option(nil), options...),
+			WithMessages(SystemMessage(summaryPrompt), UserMessage(transcript.String())),
+		)
+		return client.GetText(ctx, opts...)
+	})
+}
+
+// writeTranscript writes the text of a message as a line of a transcript
+func writeTranscript(b *strings.Builder, msg Message) {
+	fmt.Fprintf(b, "%s: %s", msg.Role, msg.Content)
+	for _, part := range msg.Parts {
+		if part.Type == PartTypeText {
+			fmt.Fprintf(b, " %s", part.Text)
+		} else {
+			fmt.Fprintf(b, " [%s]", part.Type)
+		}
+	}
+	for _, call := range msg.ToolCalls {
+		fmt.Fprintf(b, " [called %s(%s)]", call.Name, call.Arguments)
+	}
+	b.WriteString("\n")
+}
@@ -0,0 +1,194 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// countChars counts one token per character of message content, for predictable limits
+func countChars(ctx context.Context, config *Config) (int, error) {
+	tokens := 0
+	for _, msg := range config.Messages {
+		tokens += len(msg.Content)
+	}
+	return tokens, nil
+}
+
+// contents returns the content of each message
+func contents(messages []Message) string {
+	var parts []string
+	for _, msg := range messages {
+		parts = append(parts, msg.Content)
+	}
+	return strings.Join(parts, ",")
+}
+
+// windowClient returns a client that records the messages sent to its provider.
+// It reserves no output unless a request sets MaxTokens.
+func windowClient(sent *[]Message) *Client {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"), WithMaxTokens(0))
+	client.RegisterProvider(ProviderOpenAI, &MockProvider{
+		GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+			*sent = config.Messages
+			return "ok", nil
+		},
+	})
+	return client
+}
+
+func TestContextWindowDropOldest(t *testing.T) {
+	call := ToolCall{ID: "call_1", Name: "lookup", Arguments: []byte(`{}`)}
+	messages := []Message{
+		SystemMessage("sys"),
+		UserMessage("u1"),
+		AssistantToolCallMessage("a1", call),
+		ToolResultMessage("call_1", "t1"),
+		AssistantMessage("a2"),
+		UserMessage("u2"),
+		AssistantMessage("a3"),
+		UserMessage("u3"),
+	}
+
+	tests := []struct {
+		name     string
+		limit    int
+		expected string
+	}{
+		{"fits", 17, "sys,u1,a1,t1,a2,u2,a3,u3"},
+		{"drops the oldest turn with its tool calls and results", 16, "sys,u2,a3,u3"},
+		{"drops whole turns", 8, "sys,u3"},
+		{"keeps the latest turn", 5, "sys,u3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []Message
+			client := windowClient(&sent)
+			client.Use(ContextWindow(tt.limit, WithContextTokenCounter(countChars)))
+
+			if _, err := client.GetText(context.Background(), WithMessages(messages...)); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := contents(sent); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestContextWindowReservesOutput(t *testing.T) {
+	var sent []Message
+	client := windowClient(&sent)
+	client.Use(ContextWindow(10, WithContextTokenCounter(countChars)))
+
+	// 6 tokens of messages and 4 of output fill the window
+	if _, err := client.GetText(context.Background(), WithMessages(UserMessage("u1"), UserMessage("u2"), UserMessage("u3")), WithMaxTokens(4)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := contents(sent); got != "u1,u2,u3" {
+		t.Errorf("Expected all messages, got %s", got)
+	}
+
+	if _, err := client.GetText(context.Background(), WithMessages(UserMessage("u1"), UserMessage("u2"), UserMessage("u3")), WithMaxTokens(5)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := contents(sent); got != "u2,u3" {
+		t.Errorf("Expected the oldest message to be dropped, got %s", got)
+	}
+
+	client = windowClient(&sent)
+	client.Use(ContextWindow(10, WithContextTokenCounter(countChars), WithReservedOutput(7)))
+	if _, err := client.GetText(context.Background(), WithMessages(UserMessage("u1"), UserMessage("u2"), UserMessage("u3")), WithMaxTokens(4)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := contents(sent); got != "u3" {
+		t.Errorf("Expected the reserved output to apply, got %s", got)
+	}
+}
+
+func TestContextWindowExceeded(t *testing.T) {
+	var sent []Message
+	client := windowClient(&sent)
+	client.Use(ContextWindow(5, WithContextTokenCounter(countChars)))
+
+	_, err := client.GetText(context.Background(), WithMessages(UserMessage("u1"), UserMessage("too long")))
+	if !errors.Is(err, ErrContextLengthExceeded) {
+		t.Errorf("Expected ErrContextLengthExceeded, got %v", err)
+	}
+	if sent != nil {
+		t.Errorf("Expected the request not to be sent")
+	}
+}
+
+func TestContextWindowSummarize(t *testing.T) {
+	var summarized []Message
+	summarize := func(ctx context.Context, dropped []Message) (string, error) {
+		summarized = dropped
+		return "S", nil
+	}
+
+	var before, after []Message
+	var sent []Message
+	client := windowClient(&sent)
+	client.Use(ContextWindow(44,
+		WithContextTokenCounter(countChars),
+		WithTruncation(Summarize(summarize)),
+		WithTruncationHook(func(ctx context.Context, b, a []Message) {
+			before, after = b, a
+		}),
+	))
+
+	u1, a1 := strings.Repeat("u", 20), strings.Repeat("a", 20)
+	messages := []Message{SystemMessage("sys"), UserMessage(u1), AssistantMessage(a1), UserMessage("u2")}
+	if _, err := client.GetText(context.Background(), WithMessages(messages...)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// u1 is dropped with its reply, and both are summarized
+	if got := contents(summarized); got != u1+","+a1 {
+		t.Errorf("Expected u1 and a1 to be summarized, got %s", got)
+	}
+	if len(sent) != 3 || sent[1].Role != RoleSystem || !strings.HasSuffix(sent[1].Content, "\nS") || sent[2].Content != "u2" {
+		t.Errorf("Expected the summary after the system message, got %+v", sent)
+	}
+	if len(before) != 4 || len(after) != 3 {
+		t.Errorf("Expected the hook to see the truncation, got %d and %d messages", len(before), len(after))
+	}
+}
+
+func TestSummarizeWith(t *testing.T) {
+	var prompt []Message
+	summarizer := NewClient(WithProvider(ProviderOpenAI), WithModel("small-model"))
+	summarizer.RegisterProvider(ProviderOpenAI, &MockProvider{
+		GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+			if config.Model != "summary-model" {
+				t.Errorf("Expected the summary model, got %s", config.Model)
+			}
+			prompt = config.Messages
+			return "The user asked about the weather.", nil
+		},
+	})
+
+	strategy := SummarizeWith(summarizer, WithModel("summary-model"))
+	fits := func(messages []Message) (bool, error) {
+		return len(messages) <= 2, nil
+	}
+
+	messages, err := strategy(context.Background(), []Message{
+		UserMessage("What is the weather in Paris?"),
+		AssistantMessage("Sunny."),
+		UserMessage("And tomorrow?"),
+	}, fits)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(prompt) != 2 || !strings.Contains(prompt[1].Content, "user: What is the weather in Paris?\nassistant: Sunny.\n") {
+		t.Errorf("Unexpected summary prompt: %+v", prompt)
+	}
+	if len(messages) != 2 || !strings.Contains(messages[0].Content, "The user asked about the weather.") {
+		t.Errorf("Unexpected messages: %+v", messages)
+	}
+}
@@ -0,0 +1,297 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrNoTurns is returned when a conversation has fewer turns than requested
+	ErrNoTurns = errors.New("conversation does not have that many turns")
+
+	// ErrUndoStored is returned when undoing a turn of a conversation loaded from a store,
+	// since stores only add turns
+	ErrUndoStored = errors.New("cannot undo a turn of a stored conversation")
+)
+
+// Conversation holds a chat session's system prompt and history, and sends it with each
+// request so callers do not rebuild the messages themselves. Every call adds a turn: the
+// user's message followed by the replies, tool calls and tool results it produced. Failed
+// calls add nothing. A Conversation is safe for concurrent use, though concurrent calls
+// each see the history as it was when they started.
+//
+//	conv := ai.NewConversation(client, "You are a helpful assistant.")
+//	result, err := conv.Send(ctx, "What is the capital of France?")
+//	result, err = conv.Send(ctx, "And of Germany?")
+type Conversation struct {
+	client  *Client
+	options []Option
+
+	mu     sync.Mutex
+	system string
+	turns  [][]Message
+
+	// store, sessionID and version are set when the conversation is loaded from a store
+	store     ConversationStore
+	sessionID string
+	version   int
+}
+
+// NewConversation creates a conversation sent with client. The options apply to every
+// request of the conversation, before the options of each call.
+func NewConversation(client *Client, system string, options ...Option) *Conversation {
+	return &Conversation{
+		client:  client,
+		options: options,
+		system:  system,
+	}
+}
+
+// System returns the system prompt
+func (c *Conversation) System() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.system
+}
+
+// SetSystem replaces the system prompt for the following requests
+func (c *Conversation) SetSystem(system string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.system = system
+}
+
+// Messages returns the messages sent with the next request: the system prompt and the history
+func (c *Conversation) Messages() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.messages()
+}
+
+func (c *Conversation) messages() []Message {
+	var messages []Message
+	if c.system != "" {
+		messages = append(messages, SystemMessage(c.system))
+	}
+	for _, turn := range c.turns {
+		messages = append(messages, turn...)
+	}
+	return messages
+}
+
+// Turns returns the number of turns in the history
+func (c *Conversation) Turns() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.turns)
+}
+
+// Load replaces the history with a session's turns from store, and saves the following turns
+// to it. A turn that cannot be saved is not added, and the call that produced it fails; the
+// call fails with an error wrapping ErrConversationConflict if the session was changed by
+// another conversation since it was loaded. Load it again to continue from the new history.
+func (c *Conversation) Load(ctx context.Context, store ConversationStore, sessionID string) error {
+	turns, version, err := store.Load(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.turns = turns
+	c.store = store
+	c.sessionID = sessionID
+	c.version = version
+	return nil
+}
+
+// Append adds messages to the history as a turn of their own, without sending them
+func (c *Conversation) Append(ctx context.Context, messages ...Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	turn := append([]Message(nil), messages...)
+	if c.store != nil {
+		version, err := c.store.Append(ctx, c.sessionID, c.version, turn)
+		if err != nil {
+			return fmt.Errorf("failed to save conversation: %w", err)
+		}
+		c.version = version
+	}
+
+	c.turns = append(c.turns, turn)
+	return nil
+}
+
+// Undo removes the latest turn and returns its messages, or nil if the history is empty.
+// A conversation loaded from a store cannot be undone and returns ErrUndoStored; Branch
+// it from an earlier turn instead.
+func (c *Conversation) Undo() ([]Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.store != nil {
+		return nil, ErrUndoStored
+	}
+	if len(c.turns) == 0 {
+		return nil, nil
+	}
+
+	turn := c.turns[len(c.turns)-1]
+	c.turns = c.turns[:len(c.turns)-1]
+	return turn, nil
+}
+
+// Fork returns a copy of the conversation that continues independently
+func (c *Conversation) Fork() *Conversation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.branch(len(c.turns))
+}
+
+// Branch returns a copy of the conversation holding only its first turns turns, to continue
+// from an earlier point without changing the original
+func (c *Conversation) Branch(turns int) (*Conversation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if turns < 0 || turns > len(c.turns) {
+		return nil, fmt.Errorf("%w: %d of %d", ErrNoTurns, turns, len(c.turns))
+	}
+	return c.branch(turns), nil
+}
+
+// branch copies the conversation with its first n turns. Turns are never changed once
+// added, so the copies share them. Copies are not saved to the conversation's store.
+func (c *Conversation) branch(n int) *Conversation {
+	return &Conversation{
+		client:  c.client,
+		options: c.options,
+		system:  c.system,
+		turns:   append([][]Message(nil), c.turns[:n]...),
+	}
+}
+
+// callOptions returns the options for a request sending the history followed by msg,
+// and the number of messages in the history
+func (c *Conversation) callOptions(msg Message, options []Option) ([]Option, int) {
+	c.mu.Lock()
+	history := c.messages()
+	c.mu.Unlock()
+
+	opts := make([]Option, 0, len(c.options)+len(options)+1)
+	opts = append(opts, c.options...)
+	opts = append(opts, options...)
+	return append(opts, WithMessages(append(history, msg)...)), len(history)
+}
+
+// Send sends a user message with the history and adds the message and the reply to the history.
+// Any tool calls in the reply are added too; answer them with Append and ToolResultMessage, or
+// use Run to execute them. If the turn cannot be saved, the result is returned with the error.
+func (c *Conversation) Send(ctx context.Context, content string, options ...Option) (*Result, error) {
+	return c.SendMessage(ctx, UserMessage(content), options...)
+}
+
+// SendMessage is like Send for a message of any kind, such as one with images or documents
+func (c *Conversation) SendMessage(ctx context.Context, msg Message, options ...Option) (*Result, error) {
+	opts, _ := c.callOptions(msg, options)
+
+	result, err := c.client.GenerateText(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Append(ctx, msg, result.Message()); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// Run sends a user message with the history like Client.Run, executing tool calls until the
+// model answers, and adds the message, tool calls, tool results and answer to the history
+func (c *Conversation) Run(ctx context.Context, content string, options ...Option) (*RunResult, error) {
+	opts, history := c.callOptions(UserMessage(content), options)
+
+	run, err := c.client.Run(ctx, opts...)
+	if err != nil {
+		return run, err
+	}
+
+	if err := c.Append(ctx, run.Messages[history:]...); err != nil {
+		return run, err
+	}
+	return run, nil
+}
+
+// Stream streams the reply to a user message sent with the history. The message and the reply
+// are added to the history when the stream finishes; nothing is added if it fails or is closed
+// early. An error saving them is returned by the stream's Close.
+func (c *Conversation) Stream(ctx context.Context, content string, options ...Option) (*Stream, error) {
+	msg := UserMessage(content)
+
+	opts, _ := c.callOptions(msg, options)
+
+	stream, err := c.client.StreamText(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	stream.OnFinish(func(result *Result, err error) {
+		// Streams closed early have no finish reason
+		if err != nil || result.FinishReason == "" {
+			return
+		}
+		stream.closeErr = c.Append(ctx, msg, result.Message())
+	})
+	return stream, nil
+}
+
+// conversationJSON is the serialized form of a Conversation
+type conversationJSON struct {
+	System string      `json:"system,omitempty"`
+	Turns  [][]Message `json:"turns"`
+}
+
+// MarshalJSON encodes the system prompt and history, so the conversation can be saved
+func (c *Conversation) MarshalJSON() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	turns := c.turns
+	if turns == nil {
+		turns = [][]Message{}
+	}
+	return json.Marshal(conversationJSON{System: c.system, Turns: turns})
+}
+
+// UnmarshalJSON restores a saved system prompt and history, replacing the conversation's own.
+// The client and options are kept, so a saved conversation can be continued with:
+//
+//	conv := ai.NewConversation(client, "")
+//	err := json.Unmarshal(data, conv)
+func (c *Conversation) UnmarshalJSON(data []byte) error {
+	var saved conversationJSON
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.system = saved.System
+	c.turns = saved.Turns
+	return nil
+}
@@ -0,0 +1,402 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrConversationConflict is returned when a turn is appended to a session that was changed
+// since it was loaded, such as by another request on the same session
+var ErrConversationConflict = errors.New("conversation was changed concurrently")
+
+// ConversationStore holds the turns of conversations by session ID. The version of a session
+// is the number of turns stored, and appending checks it so that two requests on the same
+// session do not both add a turn after the same history. Implementations must be safe for
+// concurrent use.
+type ConversationStore interface {
+	// Load returns the turns stored for a session and its version.
+	// A session with nothing stored has no turns and version zero.
+	Load(ctx context.Context, sessionID string) ([][]Message, int, error)
+
+	// Append adds a turn to a session at the given version and returns the new version.
+	// If the session is no longer at that version, it fails with an error wrapping
+	// ErrConversationConflict and stores nothing.
+	Append(ctx context.Context, sessionID string, version int, turn []Message) (int, error)
+
+	// List returns the IDs of the stored sessions, sorted
+	List(ctx context.Context) ([]string, error)
+
+	// Delete removes a session, if it exists
+	Delete(ctx context.Context, sessionID string) error
+}
+
+// conflict returns the error for appending at version to a session at current
+func conflict(sessionID string, version, current int) error {
+	return fmt.Errorf("%w: session %s is at version %d, not %d", ErrConversationConflict, sessionID, current, version)
+}
+
+// MemoryConversationStore is a ConversationStore that keeps sessions in memory
+type MemoryConversationStore struct {
+	mu       sync.Mutex
+	sessions map[string][][]Message
+}
+
+// NewMemoryConversationStore creates an in-memory store
+func NewMemoryConversationStore() *MemoryConversationStore {
+	return &MemoryConversationStore{sessions: make(map[string][][]Message)}
+}
+
+// Load returns the turns stored for a session and its version
+func (m *MemoryConversationStore) Load(ctx context.Context, sessionID string) ([][]Message, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	turns := m.sessions[sessionID]
+	return append([][]Message(nil), turns...), len(turns), nil
+}
+
+// Append adds a turn to a session at the given version and returns the new version
+func (m *MemoryConversationStore) Append(ctx context.Context, sessionID string, version int, turn []Message) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	turns := m.sessions[sessionID]
+	if len(turns) != version {
+		return 0, conflict(sessionID, version, len(turns))
+	}
+
+	m.sessions[sessionID] = append(turns, append([]Message(nil), turn...))
+	return version + 1, nil
+}
+
+// List returns the IDs of the stored sessions
+func (m *MemoryConversationStore) List(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, 0, len(m.sessions))
+	for id := range m.sessions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Delete removes a session
+func (m *MemoryConversationStore) Delete(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+// FileConversationStore is a ConversationStore that keeps each session in a JSONL file in a
+// directory, one turn per line. Versions are checked within a process; processes sharing the
+// directory must not append to the same session concurrently.
+type FileConversationStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// fileConversationExt is the extension of the files holding sessions
+const fileConversationExt = ".jsonl"
+
+// NewFileConversationStore creates a store in dir, creating the directory if needed
+func NewFileConversationStore(dir string) (*FileConversationStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create conversation directory: %w", err)
+	}
+	return &FileConversationStore{dir: dir}, nil
+}
+
+// path returns the file holding a session. IDs are escaped so they cannot leave the directory.
+func (f *FileConversationStore) path(sessionID string) string {
+	return filepath.Join(f.dir, url.PathEscape(sessionID)+fileConversationExt)
+}
+
+// Load returns the turns stored for a session and its version
+func (f *FileConversationStore) Load(ctx context.Context, sessionID string) ([][]Message, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	turns, err := f.load(sessionID)
+	return turns, len(turns), err
+}
+
+func (f *FileConversationStore) load(sessionID string) ([][]Message, error) {
+	data, err := os.ReadFile(f.path(sessionID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var turns [][]Message
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		var turn []Message
+		if err := json.Unmarshal(scanner.Bytes(), &turn); err != nil {
+			return nil, fmt.Errorf("failed to decode turn %d of session %s: %w", len(turns)+1, sessionID, err)
+		}
+		turns = append(turns, turn)
+	}
+	return turns, scanner.Err()
+}
+
+// Append adds a turn to a session at the given version and returns the new version
+func (f *FileConversationStore) Append(ctx context.Context, sessionID string, version int, turn []Message) (int, error) {
+	line, err := json.Marshal(turn)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode turn: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	turns, err := f.load(sessionID)
+	if err != nil {
+		return 0, err
+	}
+	if len(turns) != version {
+		return 0, conflict(sessionID, version, len(turns))
+	}
+
+	file, err := os.OpenFile(f.path(sessionID), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+	return version + 1, nil
+}
+
+// List returns the IDs of the stored sessions
+func (f *FileConversationStore) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, fileConversationExt) {
+			continue
+		}
+		id, err := url.PathUnescape(strings.TrimSuffix(name, fileConversationExt))
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Delete removes a session
+func (f *FileConversationStore) Delete(ctx context.Context, sessionID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := os.Remove(f.path(sessionID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// SQLConversationStore is a ConversationStore that keeps sessions in a database table, one
+// row per turn. A primary key on the session and turn number keeps concurrent appends to a
+// session, from any process, from storing two turns at the same version.
+type SQLConversationStore struct {
+	db          *sql.DB
+	table       string
+	placeholder func(n int) string
+}
+
+// SQLConversationStoreOption is a function that modifies an SQLConversationStore
+type SQLConversationStoreOption func(*SQLConversationStore)
+
+// WithConversationTable sets the name of the table holding the turns (defaults to "ai_conversation_turns")
+func WithConversationTable(table string) SQLConversationStoreOption {
+	return func(s *SQLConversationStore) {
+		s.table = table
+	}
+}
+
+// WithNumberedPlaceholders makes queries use $1, $2, ... placeholders, as PostgreSQL drivers
+// expect, instead of ?
+func WithNumberedPlaceholders() SQLConversationStoreOption {
+	return func(s *SQLConversationStore) {
+		s.placeholder = func(n int) string {
+			return fmt.Sprintf("$%d", n)
+		}
+	}
+}
+
+// NewSQLConversationStore creates a store in db. Call CreateTable to create its table.
+func NewSQLConversationStore(db *sql.DB, options ...SQLConversationStoreOption) *SQLConversationStore {
+	s := &SQLConversationStore{
+		db:    db,
+		table: "ai_conversation_turns",
+		placeholder: func(n int) string {
+			return "?"
+		},
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s
+}
+
+// query replaces the {table} and {n} markers of a query with the table name and placeholders
+func (s *SQLConversationStore) query(query string) string {
+	query = strings.ReplaceAll(query, "{table}", s.table)
+	for n := 1; strings.Contains(query, fmt.Sprintf("{%d}", n)); n++ {
+		query = strings.ReplaceAll(query, fmt.Sprintf("{%d}", n), s.placeholder(n))
+	}
+	return query
+}
+
+// CreateTable creates the store's table if it does not exist
+func (s *SQLConversationStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.query(`CREATE TABLE IF NOT EXISTS {table} (
+		session_id VARCHAR(255) NOT NULL,
+		turn INTEGER NOT NULL,
+		messages TEXT NOT NULL,
+		PRIMARY KEY (session_id, turn)
+	)`))
+	return err
+}
+
+// Load returns the turns stored for a session and its version
+func (s *SQLConversationStore) Load(ctx context.Context, sessionID string) ([][]Message, int, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(`SELECT messages FROM {table} WHERE session_id = {1} ORDER BY turn`), sessionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var turns [][]Message
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, 0, err
+		}
+
+		var turn []Message
+		if err := json.Unmarshal([]byte(data), &turn); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode turn %d of session %s: %w", len(turns)+1, sessionID, err)
+		}
+		turns = append(turns, turn)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return turns, len(turns), nil
+}
+
+// queryRower is implemented by *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// version returns the number of turns stored for a session
+func (s *SQLConversationStore) version(ctx context.Context, q queryRower, sessionID string) (int, error) {
+	var version int
+	err := q.QueryRowContext(ctx, s.query(`SELECT COUNT(*) FROM {table} WHERE session_id = {1}`), sessionID).Scan(&version)
+	return version, err
+}
+
+// Append adds a turn to a session at the given version and returns the new version
+func (s *SQLConversationStore) Append(ctx context.Context, sessionID string, version int, turn []Message) (int, error) {
+	data, err := json.Marshal(turn)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode turn: %w", err)
+	}
+
+	err = s.insert(ctx, sessionID, version, data)
+	if errors.Is(err, ErrConversationConflict) {
+		return 0, err
+	}
+	if err != nil {
+		// A concurrent append to the session makes the insert fail, with an error that
+		// depends on the database, so check whether the session moved on
+		if current, verr := s.version(ctx, s.db, sessionID); verr == nil && current != version {
+			return 0, conflict(sessionID, version, current)
+		}
+		return 0, err
+	}
+
+	return version + 1, nil
+}
+
+// insert stores a turn as the next row of a session in a transaction
+func (s *SQLConversationStore) insert(ctx context.Context, sessionID string, version int, data []byte) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := s.version(ctx, tx, sessionID)
+	if err != nil {
+		return err
+	}
+	if current != version {
+		return conflict(sessionID, version, current)
+	}
+
+	if _, err := tx.ExecContext(ctx, s.query(`INSERT INTO {table} (session_id, turn, messages) VALUES ({1}, {2}, {3})`), sessionID, version, string(data)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// List returns the IDs of the stored sessions
+func (s *SQLConversationStore) List(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(`SELECT DISTINCT session_id FROM {table} ORDER BY session_id`))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Delete removes a session
+func (s *SQLConversationStore) Delete(ctx context.Context, sessionID string) error {
+	_, err := s.db.ExecContext(ctx, s.query(`DELETE FROM {table} WHERE session_id = {1}`), sessionID)
+	return err
+}
@@ -0,0 +1,213 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// conversationStores returns a store of each kind, each empty
+func conversationStores(t *testing.T) map[string]ConversationStore {
+	files, err := NewFileConversationStore(filepath.Join(t.TempDir(), "conversations"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "conversations.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	sqlStore := NewSQLConversationStore(db, WithConversationTable("turns"))
+	if err := sqlStore.CreateTable(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return map[string]ConversationStore{
+		"memory": NewMemoryConversationStore(),
+		"file":   files,
+		"sql":    sqlStore,
+	}
+}
+
+func TestConversationStore(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range conversationStores(t) {
+		t.Run(name, func(t *testing.T) {
+			turns, version, err := store.Load(ctx, "new")
+			if err != nil || len(turns) != 0 || version != 0 {
+				t.Fatalf("Expected an empty session, got %v, %d, %v", turns, version, err)
+			}
+
+			turn := []Message{UserMessageParts(TextPart("Look"), ImageURLPart("https://example.com/cat.png")), AssistantMessage("A cat")}
+			version, err = store.Append(ctx, "a/../b", 0, turn)
+			if err != nil || version != 1 {
+				t.Fatalf("Expected version 1, got %d, %v", version, err)
+			}
+			if version, err = store.Append(ctx, "a/../b", 1, []Message{UserMessage("u2"), AssistantMessage("a2")}); err != nil || version != 2 {
+				t.Fatalf("Expected version 2, got %d, %v", version, err)
+			}
+			if _, err := store.Append(ctx, "a/../b", 1, []Message{UserMessage("u3")}); !errors.Is(err, ErrConversationConflict) {
+				t.Errorf("Expected ErrConversationConflict, got %v", err)
+			}
+			if _, err := store.Append(ctx, "other", 0, []Message{UserMessage("o1")}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			turns, version, err = store.Load(ctx, "a/../b")
+			if err != nil || version != 2 || len(turns) != 2 {
+				t.Fatalf("Expected 2 turns, got %v, %d, %v", turns, version, err)
+			}
+			if turns[0][0].Parts[1].URL != "https://example.com/cat.png" || contents(turns[1]) != "u2,a2" {
+				t.Errorf("Unexpected turns: %+v", turns)
+			}
+
+			ids, err := store.List(ctx)
+			if err != nil || strings.Join(ids, ",") != "a/../b,other" {
+				t.Errorf("Unexpected sessions: %v, %v", ids, err)
+			}
+
+			if err := store.Delete(ctx, "a/../b"); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := store.Delete(ctx, "missing"); err != nil {
+				t.Errorf("Expected deleting a missing session to succeed, got %v", err)
+			}
+			if _, version, _ := store.Load(ctx, "a/../b"); version != 0 {
+				t.Errorf("Expected the session to be deleted, got version %d", version)
+			}
+			if ids, _ := store.List(ctx); strings.Join(ids, ",") != "other" {
+				t.Errorf("Unexpected sessions: %v", ids)
+			}
+		})
+	}
+}
+
+func TestConversationStoreConcurrentAppend(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range conversationStores(t) {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			errs := make([]error, 8)
+			for i := range errs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					_, errs[i] = store.Append(ctx, "session", 0, []Message{UserMessage("u1")})
+				}(i)
+			}
+			wg.Wait()
+
+			appended := 0
+			for _, err := range errs {
+				switch {
+				case err == nil:
+					appended++
+				case !errors.Is(err, ErrConversationConflict):
+					t.Errorf("Expected ErrConversationConflict, got %v", err)
+				}
+			}
+			if appended != 1 {
+				t.Errorf("Expected exactly one append to succeed, got %d", appended)
+			}
+			if _, version, _ := store.Load(ctx, "session"); version != 1 {
+				t.Errorf("Expected version 1, got %d", version)
+			}
+		})
+	}
+}
+
+func TestConversationLoad(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryConversationStore()
+
+	var sent []Message
+	client := echoClient(&sent)
+
+	conv := NewConversation(client, "sys")
+	if err := conv.Load(ctx, store, "session"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := conv.Send(ctx, "u1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	streamer := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	streamer.RegisterProvider(ProviderOpenAI, &MockStreamer{
+		StreamTextFunc: func(ctx context.Context, config *Config) (*Stream, error) {
+			return NewStream(&sliceReader{deltas: []Delta{{Text: "streamed", FinishReason: FinishReasonStop}}}), nil
+		},
+	})
+	behind := NewConversation(streamer, "sys")
+	if err := behind.Load(ctx, store, "session"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A second request on the session continues from the stored history
+	other := NewConversation(client, "sys")
+	if err := other.Load(ctx, store, "session"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := other.Send(ctx, "u2"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := contents(sent); got != "sys,u1,reply to 2 messages,u2" {
+		t.Errorf("Unexpected messages sent: %s", got)
+	}
+
+	// The first conversation is behind, so its turn is rejected rather than clobbering the second's
+	if _, err := conv.Send(ctx, "u3"); !errors.Is(err, ErrConversationConflict) {
+		t.Errorf("Expected ErrConversationConflict, got %v", err)
+	}
+	if conv.Turns() != 1 {
+		t.Errorf("Expected the rejected turn not to be added, got %d turns", conv.Turns())
+	}
+
+	// Streamed turns report the conflict when the stream is closed
+	stream, err := behind.Stream(ctx, "u3")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for stream.Next() {
+	}
+	if stream.Err() != nil {
+		t.Errorf("Expected the stream itself to succeed, got %v", stream.Err())
+	}
+	if err := stream.Close(); !errors.Is(err, ErrConversationConflict) {
+		t.Errorf("Expected ErrConversationConflict from Close, got %v", err)
+	}
+
+	turns, _, _ := store.Load(ctx, "session")
+	if len(turns) != 2 || contents(turns[1]) != "u2,reply to 4 messages" {
+		t.Errorf("Unexpected stored turns: %+v", turns)
+	}
+
+	// Stored turns cannot be undone
+	if _, err := other.Undo(); !errors.Is(err, ErrUndoStored) {
+		t.Errorf("Expected ErrUndoStored, got %v", err)
+	}
+	if other.Turns() != 2 {
+		t.Errorf("Expected the turns to be kept, got %d", other.Turns())
+	}
+
+	// Forks are not saved, so they can be undone
+	fork := other.Fork()
+	if _, err := fork.Send(ctx, "f1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, version, _ := store.Load(ctx, "session"); version != 2 {
+		t.Errorf("Expected the fork's turn not to be stored, got version %d", version)
+	}
+	if turn, err := fork.Undo(); err != nil || contents(turn) != "f1,reply to 6 messages" {
+		t.Errorf("Unexpected undone turn: %s, %v", contents(turn), err)
+	}
+}
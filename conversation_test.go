@@ -0,0 +1,222 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// echoClient returns a client whose replies count the messages they were sent,
+// recording the messages of the last request
+func echoClient(sent *[]Message) *Client {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockGenerator{
+		GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+			*sent = config.Messages
+			last := config.Messages[len(config.Messages)-1]
+			if last.Content == "fail" {
+				return nil, errors.New("failed")
+			}
+			return &Result{Text: fmt.Sprintf("reply to %d messages", len(config.Messages))}, nil
+		},
+	})
+	return client
+}
+
+func TestConversationSend(t *testing.T) {
+	var sent []Message
+	conv := NewConversation(echoClient(&sent), "sys", WithModel("conversation-model"))
+
+	if _, err := conv.Send(context.Background(), "u1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	result, err := conv.Send(context.Background(), "u2")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Text != "reply to 4 messages" {
+		t.Errorf("Expected the history to be sent, got %s", result.Text)
+	}
+	if got := contents(sent); got != "sys,u1,reply to 2 messages,u2" {
+		t.Errorf("Unexpected messages sent: %s", got)
+	}
+	if got := contents(conv.Messages()); got != "sys,u1,reply to 2 messages,u2,reply to 4 messages" {
+		t.Errorf("Unexpected history: %s", got)
+	}
+	if conv.Turns() != 2 {
+		t.Errorf("Expected 2 turns, got %d", conv.Turns())
+	}
+
+	// Failed calls add nothing
+	if _, err := conv.Send(context.Background(), "fail"); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if conv.Turns() != 2 {
+		t.Errorf("Expected the failed turn not to be added, got %d turns", conv.Turns())
+	}
+}
+
+func TestConversationRun(t *testing.T) {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockGenerator{
+		GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+			if config.Messages[len(config.Messages)-1].Role == RoleTool {
+				return &Result{Text: "It is sunny."}, nil
+			}
+			return &Result{ToolCalls: []ToolCall{{ID: "call_1", Name: "get_weather", Arguments: json.RawMessage(`{}`)}}}, nil
+		},
+	})
+
+	conv := NewConversation(client, "sys")
+	conv.Append(context.Background(), UserMessage("Hi"), AssistantMessage("Hello"))
+
+	run, err := conv.Run(context.Background(), "Weather?", WithToolHandler(Tool{Name: "get_weather"}, func(ctx context.Context, call ToolCall) (string, error) {
+		return "Sunny", nil
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if run.Text != "It is sunny." {
+		t.Errorf("Unexpected answer: %s", run.Text)
+	}
+
+	// The run's turn holds the user message, the tool call, its result and the answer
+	if got := contents(conv.Messages()); got != "sys,Hi,Hello,Weather?,,Sunny,It is sunny." {
+		t.Errorf("Unexpected history: %s", got)
+	}
+	if turn, _ := conv.Undo(); len(turn) != 4 {
+		t.Errorf("Expected the run to be undone as one turn, got %+v", turn)
+	}
+}
+
+func TestConversationStream(t *testing.T) {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockStreamer{
+		StreamTextFunc: func(ctx context.Context, config *Config) (*Stream, error) {
+			return NewStream(&sliceReader{deltas: []Delta{{Text: "Hello, "}, {Text: "world!", FinishReason: FinishReasonStop}}}), nil
+		},
+	})
+
+	conv := NewConversation(client, "")
+	stream, err := conv.Stream(context.Background(), "Hi")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if conv.Turns() != 0 {
+		t.Errorf("Expected the turn to be added when the stream ends")
+	}
+	for stream.Next() {
+	}
+
+	if got := contents(conv.Messages()); got != "Hi,Hello, world!" {
+		t.Errorf("Unexpected history: %s", got)
+	}
+}
+
+func TestConversationStreamClosedEarly(t *testing.T) {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockStreamer{
+		StreamTextFunc: func(ctx context.Context, config *Config) (*Stream, error) {
+			return NewStream(&sliceReader{deltas: []Delta{{Text: "Hello, "}, {Text: "world!", FinishReason: FinishReasonStop}}}), nil
+		},
+	})
+
+	conv := NewConversation(client, "")
+	stream, err := conv.Stream(context.Background(), "Hi")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	stream.Next()
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if conv.Turns() != 0 {
+		t.Errorf("Expected the truncated reply not to be added, got %s", contents(conv.Messages()))
+	}
+}
+
+func TestConversationUndoForkBranch(t *testing.T) {
+	var sent []Message
+	conv := NewConversation(echoClient(&sent), "sys")
+	for _, content := range []string{"u1", "u2", "u3"} {
+		if _, err := conv.Send(context.Background(), content); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if turn, err := conv.Undo(); err != nil || contents(turn) != "u3,reply to 6 messages" {
+		t.Errorf("Unexpected undone turn: %s, %v", contents(turn), err)
+	}
+
+	fork := conv.Fork()
+	if _, err := fork.Send(context.Background(), "f1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if conv.Turns() != 2 || fork.Turns() != 3 {
+		t.Errorf("Expected the fork to continue independently, got %d and %d turns", conv.Turns(), fork.Turns())
+	}
+
+	branch, err := conv.Branch(1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := contents(branch.Messages()); got != "sys,u1,reply to 2 messages" {
+		t.Errorf("Unexpected branch history: %s", got)
+	}
+	if _, err := conv.Branch(3); !errors.Is(err, ErrNoTurns) {
+		t.Errorf("Expected ErrNoTurns, got %v", err)
+	}
+
+	conv.Undo()
+	conv.Undo()
+	if turn, err := conv.Undo(); turn != nil || err != nil {
+		t.Errorf("Expected nothing to undo, got %+v, %v", turn, err)
+	}
+	if got := contents(conv.Messages()); got != "sys" {
+		t.Errorf("Expected only the system prompt, got %s", got)
+	}
+}
+
+func TestConversationJSON(t *testing.T) {
+	var sent []Message
+	client := echoClient(&sent)
+
+	conv := NewConversation(client, "sys")
+	conv.Append(context.Background(), UserMessageParts(TextPart("Look"), ImageURLPart("https://example.com/cat.png")), AssistantMessage("A cat"))
+	conv.Append(context.Background(), AssistantToolCallMessage("", ToolCall{ID: "call_1", Name: "lookup", Arguments: json.RawMessage(`{"q":"cats"}`)}), ToolResultMessage("call_1", "found"))
+
+	data, err := json.Marshal(conv)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	restored := NewConversation(client, "")
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if restored.System() != "sys" || restored.Turns() != 2 {
+		t.Fatalf("Unexpected restored conversation: %s", data)
+	}
+
+	messages := restored.Messages()
+	if messages[1].Parts[1].URL != "https://example.com/cat.png" || string(messages[3].ToolCalls[0].Arguments) != `{"q":"cats"}` || messages[4].ToolCallID != "call_1" {
+		t.Errorf("Unexpected restored messages: %+v", messages)
+	}
+
+	// The restored conversation continues with the client
+	if _, err := restored.Send(context.Background(), "more"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sent) != 6 {
+		t.Errorf("Expected the restored history to be sent, got %d messages", len(sent))
+	}
+
+	empty, err := json.Marshal(NewConversation(client, ""))
+	if err != nil || string(empty) != `{"turns":[]}` {
+		t.Errorf("Unexpected empty conversation: %s, %v", empty, err)
+	}
+}
@@ -0,0 +1,336 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned when a request would exceed the Budget of its context
+var ErrBudgetExceeded = errors.New("cost budget exceeded")
+
+// Price is the cost of a model's tokens in US dollars per million tokens
+type Price struct {
+	Input  float64
+	Output float64
+
+	// CachedInput is the rate for input tokens read from the prompt cache (defaults to Input)
+	CachedInput float64
+}
+
+// Cost returns the cost of the given usage in US dollars
+func (p Price) Cost(usage Usage) float64 {
+	cachedRate := p.CachedInput
+	if cachedRate == 0 {
+		cachedRate = p.Input
+	}
+
+	uncached := usage.InputTokens - usage.CachedInputTokens
+	return (float64(uncached)*p.Input +
+		float64(usage.CachedInputTokens)*cachedRate +
+		float64(usage.OutputTokens)*p.Output) / 1e6
+}
+
+// defaultPrices are the list prices published by each provider. Prices change,
+// so check them against the provider's pricing page and override them with Pricing.Set.
+var defaultPrices = map[Provider]map[string]Price{
+	ProviderOpenAI: {
+		"gpt-4o":        {Input: 2.50, Output: 10.00, CachedInput: 1.25},
+		"gpt-4o-mini":   {Input: 0.15, Output: 0.60, CachedInput: 0.075},
+		"gpt-4.1":       {Input: 2.00, Output: 8.00, CachedInput: 0.50},
+		"gpt-4.1-mini":  {Input: 0.40, Output: 1.60, CachedInput: 0.10},
+		"gpt-4.1-nano":  {Input: 0.10, Output: 0.40, CachedInput: 0.025},
+		"gpt-4-turbo":   {Input: 10.00, Output: 30.00},
+		"gpt-4":         {Input: 30.00, Output: 60.00},
+		"gpt-3.5-turbo": {Input: 0.50, Output: 1.50},
+		"o1":            {Input: 15.00, Output: 60.00, CachedInput: 7.50},
+		"o1-mini":       {Input: 1.10, Output: 4.40, CachedInput: 0.55},
+		"o3":            {Input: 2.00, Output: 8.00, CachedInput: 0.50},
+		"o3-mini":       {Input: 1.10, Output: 4.40, CachedInput: 0.55},
+		"o4-mini":       {Input: 1.10, Output: 4.40, CachedInput: 0.275},
+	},
+	ProviderAnthropic: {
+		"claude-opus-4":     {Input: 15.00, Output: 75.00, CachedInput: 1.50},
+		"claude-sonnet-4":   {Input: 3.00, Output: 15.00, CachedInput: 0.30},
+		"claude-3-7-sonnet": {Input: 3.00, Output: 15.00, CachedInput: 0.30},
+		"claude-3-5-sonnet": {Input: 3.00, Output: 15.00, CachedInput: 0.30},
+		"claude-3-5-haiku":  {Input: 0.80, Output: 4.00, CachedInput: 0.08},
+		"claude-3-opus":     {Input: 15.00, Output: 75.00, CachedInput: 1.50},
+		"claude-3-haiku":    {Input: 0.25, Output: 1.25, CachedInput: 0.03},
+	},
+}
+
+// Pricing is a registry of model prices. It is safe for concurrent use.
+type Pricing struct {
+	mu     sync.RWMutex
+	prices map[Provider]map[string]Price
+}
+
+// NewPricing creates a registry holding the default prices of the built-in providers
+func NewPricing() *Pricing {
+	p := &Pricing{prices: make(map[Provider]map[string]Price)}
+	for provider, models := range defaultPrices {
+		for model, price := range models {
+			p.Set(provider, model, price)
+		}
+	}
+	return p
+}
+
+// Set sets the price of a model, replacing any existing price. The price also applies to
+// versions of the model, such as "gpt-4o-2024-08-06" for "gpt-4o", that have no price of their own.
+func (p *Pricing) Set(provider Provider, model string, price Price) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.prices[provider] == nil {
+		p.prices[provider] = make(map[string]Price)
+	}
+	p.prices[provider][model] = price
+}
+
+// Price returns the price of a model, matching the longest registered model name
+// that the model equals or starts with followed by a dash
+func (p *Pricing) Price(provider Provider, model string) (Price, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return lookupModel(p.prices[provider], model)
+}
+
+// WithCostTags labels the request so its cost is also counted under each tag in a CostTracker
+func WithCostTags(tags ...string) Option {
+	return func(c *Config) {
+		c.CostTags = append(c.CostTags, tags...)
+	}
+}
+
+// Budget limits the cost of the requests made with a context. It is safe for concurrent use.
+//
+//	budget := ai.NewBudget(0.50)
+//	ctx = ai.WithBudget(ctx, budget)
+type Budget struct {
+	mu    sync.Mutex
+	limit float64
+	spent float64
+}
+
+// NewBudget creates a budget of limit US dollars. A limit of zero or less only tracks spending.
+func NewBudget(limit float64) *Budget {
+	return &Budget{limit: limit}
+}
+
+// Spent returns the cost of the requests made with the budget so far
+func (b *Budget) Spent() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.spent
+}
+
+// Remaining returns what is left of the budget, or +Inf if it has no limit
+func (b *Budget) Remaining() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limit <= 0 {
+		return math.Inf(1)
+	}
+	return math.Max(b.limit-b.spent, 0)
+}
+
+// check returns an error wrapping ErrBudgetExceeded if a request of the
+// estimated cost would take spending over the limit
+func (b *Budget) check(estimate float64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limit <= 0 {
+		return nil
+	}
+	if b.spent >= b.limit || b.spent+estimate > b.limit {
+		return fmt.Errorf("%w: spent $%.4f of $%.4f, request estimated at $%.4f", ErrBudgetExceeded, b.spent, b.limit, estimate)
+	}
+	return nil
+}
+
+func (b *Budget) add(cost float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.spent += cost
+}
+
+type budgetKey struct{}
+
+// WithBudget returns a context whose requests are charged to budget by a CostTracker.
+// Requests whose estimated cost would exceed the budget fail with ErrBudgetExceeded.
+// Concurrent requests are checked before any of them is charged, so together they may
+// overshoot the budget.
+func WithBudget(ctx context.Context, budget *Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, budget)
+}
+
+// BudgetFromContext returns the budget set on a context with WithBudget
+func BudgetFromContext(ctx context.Context) (*Budget, bool) {
+	budget, ok := ctx.Value(budgetKey{}).(*Budget)
+	return budget, ok
+}
+
+// CostTracker prices each request from its token usage, setting Result.Cost, charging
+// the context's Budget and keeping running totals by model and tag. Add its middleware
+// to a client to track the client's requests:
+//
+//	tracker := ai.NewCostTracker()
+//	client.Use(tracker.Middleware())
+//
+// Requests to models without a price are not counted, nor are results served from a Cache.
+type CostTracker struct {
+	pricing *Pricing
+
+	mu      sync.Mutex
+	total   float64
+	byModel map[string]float64
+	byTag   map[string]float64
+}
+
+// CostTrackerOption is a function that modifies a CostTracker
+type CostTrackerOption func(*CostTracker)
+
+// WithPricing sets the prices used by the tracker (defaults to NewPricing())
+func WithPricing(pricing *Pricing) CostTrackerOption {
+	return func(t *CostTracker) {
+		t.pricing = pricing
+	}
+}
+
+// NewCostTracker creates a new cost tracker
+func NewCostTracker(options ...CostTrackerOption) *CostTracker {
+	t := &CostTracker{
+		byModel: make(map[string]float64),
+		byTag:   make(map[string]float64),
+	}
+
+	for _, opt := range options {
+		opt(t)
+	}
+
+	if t.pricing == nil {
+		t.pricing = NewPricing()
+	}
+
+	return t
+}
+
+// Total returns the cost of all requests tracked so far
+func (t *CostTracker) Total() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.total
+}
+
+// ByModel returns the cost of the requests tracked so far for each model that served them
+func (t *CostTracker) ByModel() map[string]float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return copyCosts(t.byModel)
+}
+
+// ByTag returns the cost of the requests tracked so far for each tag set with WithCostTags
+func (t *CostTracker) ByTag() map[string]float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return copyCosts(t.byTag)
+}
+
+func copyCosts(costs map[string]float64) map[string]float64 {
+	copied := make(map[string]float64, len(costs))
+	for key, cost := range costs {
+		copied[key] = cost
+	}
+	return copied
+}
+
+// Middleware returns middleware that prices each request. For streams, the cost is
+// counted when the stream ends and is included in the stream's Result.
+func (t *CostTracker) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			budget, hasBudget := BudgetFromContext(ctx)
+			if hasBudget {
+				if err := budget.check(t.estimate(req.Config)); err != nil {
+					return nil, err
+				}
+			}
+
+			resp, err := next(ctx, req)
+			if err == nil && resp.Stream != nil {
+				stream := resp.Stream
+				stream.OnFinish(func(result *Result, err error) {
+					t.charge(req.Config, result, budget)
+					stream.metadata.Cost = result.Cost
+				})
+				return resp, nil
+			}
+
+			// Invalid structured responses are returned with their result, and are paid for
+			if resp != nil && resp.Result != nil {
+				t.charge(req.Config, resp.Result, budget)
+			}
+			return resp, err
+		}
+	}
+}
+
+// estimate returns the most a request can cost, from its estimated input and maximum output
+func (t *CostTracker) estimate(config *Config) float64 {
+	price, ok := t.pricing.Price(config.Provider, config.Model)
+	if !ok {
+		return 0
+	}
+	return price.Cost(Usage{InputTokens: EstimateTokens(config), OutputTokens: config.MaxTokens})
+}
+
+// charge sets the cost of a result and adds it to the totals and budget. Results are priced
+// by the provider and model that served them, such as a Fallback target, falling back to the
+// requested ones.
+func (t *CostTracker) charge(config *Config, result *Result, budget *Budget) {
+	if result.Cached {
+		return
+	}
+
+	provider, model := config.Provider, config.Model
+	if result.Provider != "" {
+		provider = result.Provider
+	}
+	if result.Model != "" {
+		model = result.Model
+	}
+
+	price, ok := t.pricing.Price(provider, model)
+	if !ok && provider == config.Provider {
+		price, ok = t.pricing.Price(provider, config.Model)
+	}
+	if !ok {
+		return
+	}
+
+	result.Cost = price.Cost(result.Usage)
+	if budget != nil {
+		budget.add(result.Cost)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total += result.Cost
+	t.byModel[model] += result.Cost
+	for _, tag := range config.CostTags {
+		t.byTag[tag] += result.Cost
+	}
+}
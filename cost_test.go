@@ -0,0 +1,218 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+// approxEqual compares costs, which are not exact in floating point
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestPriceCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		price    Price
+		usage    Usage
+		expected float64
+	}{
+		{
+			name:     "input and output",
+			price:    Price{Input: 2.50, Output: 10.00},
+			usage:    Usage{InputTokens: 1000, OutputTokens: 500},
+			expected: 0.0025 + 0.005,
+		},
+		{
+			name:     "cached input",
+			price:    Price{Input: 2.50, Output: 10.00, CachedInput: 1.25},
+			usage:    Usage{InputTokens: 1000, CachedInputTokens: 800, OutputTokens: 0},
+			expected: 0.0005 + 0.001,
+		},
+		{
+			name:     "cached input without a cached rate",
+			price:    Price{Input: 2.50, Output: 10.00},
+			usage:    Usage{InputTokens: 1000, CachedInputTokens: 800},
+			expected: 0.0025,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.price.Cost(tt.usage); !approxEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPricing(t *testing.T) {
+	pricing := NewPricing()
+
+	tests := []struct {
+		model    string
+		expected Price
+		found    bool
+	}{
+		{"gpt-4o", defaultPrices[ProviderOpenAI]["gpt-4o"], true},
+		{"gpt-4o-2024-08-06", defaultPrices[ProviderOpenAI]["gpt-4o"], true},
+		{"gpt-4o-mini-2024-07-18", defaultPrices[ProviderOpenAI]["gpt-4o-mini"], true},
+		{"o1-mini-2024-09-12", defaultPrices[ProviderOpenAI]["o1-mini"], true},
+		{"gpt-4-0613", defaultPrices[ProviderOpenAI]["gpt-4"], true},
+		{"gpt-4oo", Price{}, false},
+		{"unknown-model", Price{}, false},
+	}
+
+	for _, tt := range tests {
+		price, ok := pricing.Price(ProviderOpenAI, tt.model)
+		if ok != tt.found || price != tt.expected {
+			t.Errorf("%s: expected %+v, %v, got %+v, %v", tt.model, tt.expected, tt.found, price, ok)
+		}
+	}
+
+	// Prices can be overridden and added
+	pricing.Set(ProviderOpenAI, "gpt-4o", Price{Input: 1, Output: 2})
+	pricing.Set(ProviderOpenAI, "my-fine-tune", Price{Input: 3, Output: 4})
+	if price, _ := pricing.Price(ProviderOpenAI, "gpt-4o-2024-08-06"); price.Input != 1 {
+		t.Errorf("Expected the overridden price, got %+v", price)
+	}
+	if _, ok := pricing.Price(ProviderOpenAI, "my-fine-tune"); !ok {
+		t.Errorf("Expected the added price")
+	}
+	if _, ok := NewPricing().Price(ProviderOpenAI, "my-fine-tune"); ok {
+		t.Errorf("Expected registries not to share prices")
+	}
+}
+
+func TestCostTracker(t *testing.T) {
+	pricing := NewPricing()
+	pricing.Set(ProviderOpenAI, "test-model", Price{Input: 1, Output: 2, CachedInput: 0.5})
+
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockGenerator{
+		GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+			return &Result{
+				Text:  "Hello!",
+				Model: config.Model + "-2024",
+				Usage: Usage{InputTokens: 1000000, CachedInputTokens: 500000, OutputTokens: 500000},
+			}, nil
+		},
+	})
+
+	tracker := NewCostTracker(WithPricing(pricing))
+	client.Use(tracker.Middleware())
+
+	result, err := client.GenerateText(context.Background(), WithCostTags("team-a"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !approxEqual(result.Cost, 0.5+0.25+1) {
+		t.Errorf("Expected a cost of 1.75, got %v", result.Cost)
+	}
+
+	if _, err := client.GenerateText(context.Background(), WithCostTags("team-a", "team-b")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Models without a price are not counted
+	if _, err := client.GenerateText(context.Background(), WithModel("unknown"), WithCostTags("team-b")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !approxEqual(tracker.Total(), 3.5) {
+		t.Errorf("Expected a total of 3.5, got %v", tracker.Total())
+	}
+	if byModel := tracker.ByModel(); !approxEqual(byModel["test-model-2024"], 3.5) || len(byModel) != 1 {
+		t.Errorf("Unexpected costs by model: %v", byModel)
+	}
+	if byTag := tracker.ByTag(); !approxEqual(byTag["team-a"], 3.5) || !approxEqual(byTag["team-b"], 1.75) {
+		t.Errorf("Unexpected costs by tag: %v", byTag)
+	}
+}
+
+func TestCostTrackerStream(t *testing.T) {
+	pricing := NewPricing()
+	pricing.Set(ProviderOpenAI, "test-model", Price{Input: 1, Output: 2})
+
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockStreamer{
+		StreamTextFunc: func(ctx context.Context, config *Config) (*Stream, error) {
+			return NewStream(&sliceReader{deltas: []Delta{
+				{Text: "Hello"},
+				{FinishReason: FinishReasonStop, Usage: &Usage{InputTokens: 1000000, OutputTokens: 1000000}},
+			}}), nil
+		},
+	})
+
+	tracker := NewCostTracker(WithPricing(pricing))
+	client.Use(tracker.Middleware())
+
+	stream, err := client.StreamText(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for stream.Next() {
+	}
+
+	if cost := stream.Result().Cost; !approxEqual(cost, 3) {
+		t.Errorf("Expected the stream result to cost 3, got %v", cost)
+	}
+	if !approxEqual(tracker.Total(), 3) {
+		t.Errorf("Expected a total of 3, got %v", tracker.Total())
+	}
+}
+
+func TestBudget(t *testing.T) {
+	pricing := NewPricing()
+	pricing.Set(ProviderOpenAI, "test-model", Price{Input: 1, Output: 2})
+
+	calls := 0
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockGenerator{
+		GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+			calls++
+			return &Result{Text: "Hello!", Usage: Usage{OutputTokens: 300000}}, nil
+		},
+	})
+
+	tracker := NewCostTracker(WithPricing(pricing))
+	client.Use(tracker.Middleware())
+
+	budget := NewBudget(1)
+	ctx := WithBudget(context.Background(), budget)
+
+	// Each call costs $0.60, so the second reaches the limit
+	for i := 0; i < 2; i++ {
+		if _, err := client.GenerateText(ctx, WithMessages(UserMessage("Hi"))); err != nil {
+			t.Fatalf("Call %d: expected no error, got %v", i, err)
+		}
+	}
+	if !approxEqual(budget.Spent(), 1.2) || budget.Remaining() != 0 {
+		t.Errorf("Expected $1.20 spent and nothing remaining, got %v, %v", budget.Spent(), budget.Remaining())
+	}
+
+	_, err := client.GenerateText(ctx, WithMessages(UserMessage("Hi")))
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected ErrBudgetExceeded, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the provider not to be called once the budget is spent, got %d calls", calls)
+	}
+
+	// Requests whose maximum output would exceed the budget are rejected up front
+	_, err = client.GenerateText(WithBudget(context.Background(), NewBudget(1)), WithMaxTokens(1000000))
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected ErrBudgetExceeded, got %v", err)
+	}
+
+	// A budget without a limit only tracks spending
+	unlimited := NewBudget(0)
+	if _, err := client.GenerateText(WithBudget(context.Background(), unlimited), WithMaxTokens(1000000)); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if !approxEqual(unlimited.Spent(), 0.6) || !math.IsInf(unlimited.Remaining(), 1) {
+		t.Errorf("Unexpected unlimited budget: %v, %v", unlimited.Spent(), unlimited.Remaining())
+	}
+}
@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Categories of errors returned by provider APIs. An *APIError matches its category with errors.Is:
+//
+//	if errors.Is(err, ai.ErrRateLimited) { ... }
+var (
+	ErrAuthentication        = errors.New("authentication failed")
+	ErrPermissionDenied      = errors.New("permission denied")
+	ErrNotFound              = errors.New("not found")
+	ErrInvalidRequest        = errors.New("invalid request")
+	ErrContextLengthExceeded = errors.New("context length exceeded")
+	ErrContentPolicy         = errors.New("content policy violation")
+	ErrRateLimited           = errors.New("rate limited")
+	ErrQuotaExceeded         = errors.New("quota exceeded")
+	ErrOverloaded            = errors.New("provider overloaded")
+	ErrServerError           = errors.New("provider server error")
+)
+
+// APIError is returned when a provider API reports an error
+type APIError struct {
+	// Provider is the provider that returned the error
+	Provider Provider
+
+	// StatusCode is the HTTP status code, or 0 for errors reported mid-stream
+	StatusCode int
+
+	// Type and Code are the provider's identifiers for the error, where it reports them
+	Type string
+	Code string
+
+	// Message is the provider's description of the error
+	Message string
+
+	// RequestID is the provider's identifier for the failed request
+	RequestID string
+
+	// Retryable reports whether the request may succeed if it is sent again
+	Retryable bool
+
+	// Body is the raw response body
+	Body []byte
+
+	// Category is one of the error categories above, matched by errors.Is
+	Category error
+}
+
+func (e *APIError) Error() string {
+	var details []string
+	if e.StatusCode != 0 {
+		details = append(details, fmt.Sprintf("status %d", e.StatusCode))
+	}
+	if e.Code != "" {
+		details = append(details, e.Code)
+	} else if e.Type != "" {
+		details = append(details, e.Type)
+	}
+
+	message := e.Message
+	if message == "" {
+		message = string(e.Body)
+	}
+
+	if len(details) == 0 {
+		return fmt.Sprintf("%s API error: %s", e.Provider, message)
+	}
+	return fmt.Sprintf("%s API error (%s): %s", e.Provider, strings.Join(details, ", "), message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Category
+}
+
+// StatusCategory returns the error category for an HTTP status code.
+// Providers refine it using the error type and code they report.
+func StatusCategory(status int) error {
+	switch {
+	case status == http.StatusUnauthorized:
+		return ErrAuthentication
+	case status == http.StatusForbidden:
+		return ErrPermissionDenied
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status == http.StatusServiceUnavailable || status == 529:
+		return ErrOverloaded
+	case status >= http.StatusInternalServerError:
+		return ErrServerError
+	case status >= http.StatusBadRequest:
+		return ErrInvalidRequest
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	err := fmt.Errorf("request failed: %w", &APIError{
+		Provider:   ProviderOpenAI,
+		StatusCode: 429,
+		Code:       "rate_limit_exceeded",
+		Message:    "Rate limit reached",
+		Retryable:  true,
+		Category:   ErrRateLimited,
+	})
+
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+	if errors.Is(err, ErrOverloaded) {
+		t.Errorf("Expected only the error's own category to match")
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.Retryable {
+		t.Fatalf("Expected a retryable *APIError, got %v", err)
+	}
+	if apiErr.Error() != "openai API error (status 429, rate_limit_exceeded): Rate limit reached" {
+		t.Errorf("Unexpected message: %s", apiErr.Error())
+	}
+}
+
+func TestStatusCategory(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected error
+	}{
+		{200, nil},
+		{400, ErrInvalidRequest},
+		{401, ErrAuthentication},
+		{403, ErrPermissionDenied},
+		{404, ErrNotFound},
+		{413, ErrInvalidRequest},
+		{429, ErrRateLimited},
+		{500, ErrServerError},
+		{503, ErrOverloaded},
+		{529, ErrOverloaded},
+	}
+
+	for _, tt := range tests {
+		if category := StatusCategory(tt.status); category != tt.expected {
+			t.Errorf("StatusCategory(%d) = %v, expected %v", tt.status, category, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+var (
+	// ErrTimeout matches requests that timed out, including attempts cut short by WithAttemptTimeout
+	ErrTimeout = errors.New("request timed out")
+
+	// ErrAllTargetsFailed is returned when every target of a Fallback failed
+	ErrAllTargetsFailed = errors.New("all fallback targets failed")
+)
+
+// FallbackTarget is a provider and model tried by a Fallback
+type FallbackTarget struct {
+	// Provider identifies the provider; it is set on the request config and the Result
+	Provider Provider
+
+	// LLM is the provider implementation
+	LLM LLMProvider
+
+	// Model is the model to request, or empty to keep the requested model
+	Model string
+}
+
+// Fallback is an LLMProvider that tries an ordered list of targets, moving on to the next
+// when a target fails with a temporary error such as a rate limit, and returning the first
+// response. Register it with a client like any other provider:
+//
+//	client.RegisterProvider(ai.ProviderAnthropic, ai.NewFallback([]ai.FallbackTarget{
+//		{Provider: ai.ProviderAnthropic, LLM: anthropic.New()},
+//		{Provider: ai.ProviderOpenAI, LLM: openai.New(), Model: "gpt-4o"},
+//	}))
+//
+// Results from GenerateText, GenerateObject and streams record the target that served them
+// in Result.Provider and Result.Model.
+type Fallback struct {
+	targets []FallbackTarget
+	on      []error
+	timeout time.Duration
+	hook    func(ctx context.Context, target FallbackTarget, err error)
+}
+
+// FallbackOption is a function that modifies a Fallback
+type FallbackOption func(*Fallback)
+
+// WithFallbackOn sets the error categories that move on to the next target (defaults to
+// ErrRateLimited, ErrOverloaded, ErrServerError and ErrTimeout). Other errors are returned
+// as they are.
+func WithFallbackOn(categories ...error) FallbackOption {
+	return func(f *Fallback) {
+		f.on = categories
+	}
+}
+
+// WithAttemptTimeout limits the time each target has to respond, so that a slow target falls
+// back with ErrTimeout. For streams, it limits the time until the response starts.
+func WithAttemptTimeout(timeout time.Duration) FallbackOption {
+	return func(f *Fallback) {
+		f.timeout = timeout
+	}
+}
+
+// WithFallbackHook sets a function called with each target that failed and was fallen back from
+func WithFallbackHook(hook func(ctx context.Context, target FallbackTarget, err error)) FallbackOption {
+	return func(f *Fallback) {
+		f.hook = hook
+	}
+}
+
+// NewFallback creates a provider that tries targets in order
+func NewFallback(targets []FallbackTarget, options ...FallbackOption) *Fallback {
+	f := &Fallback{
+		targets: targets,
+		on:      []error{ErrRateLimited, ErrOverloaded, ErrServerError, ErrTimeout},
+	}
+
+	for _, opt := range options {
+		opt(f)
+	}
+
+	return f
+}
+
+// isTimeout reports whether err is a timeout, from a context deadline or the network
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// shouldFallBack reports whether a target's error moves on to the next target
+func (f *Fallback) shouldFallBack(err error) bool {
+	for _, category := range f.on {
+		if errors.Is(err, category) || (category == ErrTimeout && isTimeout(err)) {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackAttempt is an attempt at a target. Its context is cancelled with ErrTimeout once
+// the attempt timeout passes, unless stop is called first.
+type fallbackAttempt struct {
+	ctx    context.Context
+	stop   func()
+	cancel context.CancelCauseFunc
+}
+
+// attempt starts an attempt at a target
+func (f *Fallback) attempt(ctx context.Context) *fallbackAttempt {
+	attemptCtx, cancel := context.WithCancelCause(ctx)
+	a := &fallbackAttempt{ctx: attemptCtx, stop: func() {}, cancel: cancel}
+	if f.timeout > 0 {
+		timer := time.AfterFunc(f.timeout, func() { cancel(ErrTimeout) })
+		a.stop = func() { timer.Stop() }
+	}
+	return a
+}
+
+// end releases the attempt's context once the response has been read
+func (a *fallbackAttempt) end() {
+	a.stop()
+	a.cancel(nil)
+}
+
+// targetConfig returns the config for a request to a target
+func targetConfig(config *Config, target FallbackTarget) *Config {
+	targeted := *config
+	if target.Provider != "" {
+		targeted.Provider = target.Provider
+	}
+	if target.Model != "" {
+		targeted.Model = target.Model
+	}
+	return &targeted
+}
+
+// try calls attempt with each target in turn until one succeeds or fails with an error that
+// does not fall back. Targets that cannot serve the request are skipped. attempt ends the
+// attempt once it has read the response.
+func (f *Fallback) try(ctx context.Context, config *Config, attempt func(a *fallbackAttempt, target FallbackTarget, config *Config) error) error {
+	var errs []error
+	for _, target := range f.targets {
+		a := f.attempt(ctx)
+
+		err := attempt(a, target, targetConfig(config, target))
+		if err == nil {
+			return nil
+		}
+		a.end()
+
+		if errors.Is(context.Cause(a.ctx), ErrTimeout) && !errors.Is(err, ErrTimeout) {
+			err = fmt.Errorf("%w after %s: %w", ErrTimeout, f.timeout, err)
+		}
+		unsupported := errors.Is(err, ErrStreamingNotSupported) || errors.Is(err, ErrToolsNotSupported)
+		if ctx.Err() != nil || !unsupported && !f.shouldFallBack(err) {
+			return err
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", target.Provider, err))
+		if f.hook != nil {
+			f.hook(ctx, target, err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf("%w: no targets", ErrAllTargetsFailed)
+	}
+	return fmt.Errorf("%w: %w", ErrAllTargetsFailed, errors.Join(errs...))
+}
+
+// served records the target that served a result
+func served(result *Result, config *Config) {
+	result.Provider = config.Provider
+	if result.Model == "" {
+		result.Model = config.Model
+	}
+}
+
+// CountTokens counts tokens with the first target
+func (f *Fallback) CountTokens(ctx context.Context, config *Config) (int, error) {
+	if len(f.targets) == 0 {
+		return EstimateTokens(config), nil
+	}
+	return countTokens(ctx, f.targets[0].LLM, targetConfig(config, f.targets[0]))
+}
+
+func (f *Fallback) GetText(ctx context.Context, config *Config) (string, error) {
+	var text string
+	err := f.try(ctx, config, func(a *fallbackAttempt, target FallbackTarget, config *Config) error {
+		defer a.end()
+
+		var err error
+		text, err = target.LLM.GetText(a.ctx, config)
+		return err
+	})
+	return text, err
+}
+
+func (f *Fallback) GetObject(ctx context.Context, config *Config, target interface{}) error {
+	return f.try(ctx, config, func(a *fallbackAttempt, t FallbackTarget, config *Config) error {
+		defer a.end()
+		return t.LLM.GetObject(a.ctx, config, target)
+	})
+}
+
+func (f *Fallback) GenerateText(ctx context.Context, config *Config) (*Result, error) {
+	var result *Result
+	err := f.try(ctx, config, func(a *fallbackAttempt, target FallbackTarget, config *Config) error {
+		defer a.end()
+
+		var err error
+		result, err = generateText(a.ctx, target.LLM, config)
+		if err == nil {
+			served(result, config)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GenerateObject gets a structured response from the first target that responds. An invalid
+// response is returned with its result rather than falling back, so that it can be repaired.
+func (f *Fallback) GenerateObject(ctx context.Context, config *Config, target interface{}) (*Result, error) {
+	var result *Result
+	err := f.try(ctx, config, func(a *fallbackAttempt, t FallbackTarget, config *Config) error {
+		defer a.end()
+
+		var err error
+		result, err = getObject(a.ctx, t.LLM, config, target)
+		if result != nil {
+			served(result, config)
+		}
+		return err
+	})
+	return result, err
+}
+
+// StreamText streams from the first target that starts responding. A target that fails before
+// sending any text or tool call falls back, including with an error reported in the stream;
+// errors after that are reported by the stream. Targets that cannot stream are skipped.
+func (f *Fallback) StreamText(ctx context.Context, config *Config) (*Stream, error) {
+	return f.stream(ctx, config, func(ctx context.Context, target FallbackTarget, config *Config) (*Stream, error) {
+		streamer, ok := target.LLM.(StreamingProvider)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrStreamingNotSupported, config.Provider)
+		}
+		return streamer.StreamText(ctx, config)
+	})
+}
+
+// StreamObject streams a structured response from the first target that starts responding,
+// falling back like StreamText
+func (f *Fallback) StreamObject(ctx context.Context, config *Config, target interface{}) (*Stream, error) {
+	return f.stream(ctx, config, func(ctx context.Context, t FallbackTarget, config *Config) (*Stream, error) {
+		streamer, ok := t.LLM.(ObjectStreamer)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrStreamingNotSupported, config.Provider)
+		}
+		return streamer.StreamObject(ctx, config, target)
+	})
+}
+
+// stream opens a stream with each target in turn, reading until the response starts so that
+// errors reported at the start of a stream fall back too
+func (f *Fallback) stream(ctx context.Context, config *Config, open func(ctx context.Context, target FallbackTarget, config *Config) (*Stream, error)) (*Stream, error) {
+	var stream *Stream
+	err := f.try(ctx, config, func(a *fallbackAttempt, target FallbackTarget, config *Config) error {
+		s, err := open(a.ctx, target, config)
+		if err != nil {
+			return err
+		}
+
+		if err := startStream(s); err != nil {
+			s.err = err
+			s.Close()
+			return err
+		}
+
+		// The response has started, so the attempt lasts as long as the stream
+		a.stop()
+		s.OnFinish(func(*Result, error) { a.cancel(nil) })
+
+		s.metadata.Provider = config.Provider
+		if s.metadata.Model == "" {
+			s.metadata.Model = config.Model
+		}
+		stream = s
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// startStream reads a stream's deltas until the first text or tool call, and returns the error
+// if the stream fails first. The deltas read are returned again by the stream's reader.
+func startStream(s *Stream) error {
+	peeked := &peekedReader{reader: s.reader}
+	for {
+		delta, err := s.reader.Recv()
+		if err == io.EOF {
+			peeked.err = err
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		peeked.deltas = append(peeked.deltas, delta)
+		if delta.Text != "" || delta.ToolCall != nil {
+			break
+		}
+	}
+
+	s.reader = peeked
+	return nil
+}
+
+// peekedReader returns deltas already read from a stream, then its remaining deltas
+type peekedReader struct {
+	reader StreamReader
+	deltas []Delta
+	err    error
+}
+
+func (r *peekedReader) Recv() (Delta, error) {
+	if len(r.deltas) > 0 {
+		delta := r.deltas[0]
+		r.deltas = r.deltas[1:]
+		return delta, nil
+	}
+	if r.err != nil {
+		return Delta{}, r.err
+	}
+	return r.reader.Recv()
+}
+
+func (r *peekedReader) Close() error {
+	return r.reader.Close()
+}
@@ -0,0 +1,262 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// failingGenerator returns a generator that fails with err, counting its calls
+func failingGenerator(err error, calls *int) *MockGenerator {
+	return &MockGenerator{
+		MockProvider: MockProvider{
+			GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+				*calls++
+				return "", err
+			},
+			GetObjectFunc: func(ctx context.Context, config *Config, target interface{}) error {
+				*calls++
+				return err
+			},
+		},
+		GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+			*calls++
+			return nil, err
+		},
+	}
+}
+
+// modelGenerator returns a generator that replies with the requested model
+func modelGenerator() *MockGenerator {
+	return &MockGenerator{
+		MockProvider: MockProvider{
+			GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+				return config.Model, nil
+			},
+		},
+		GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+			return &Result{Text: config.Model}, nil
+		},
+	}
+}
+
+func TestFallback(t *testing.T) {
+	overloaded := &APIError{Provider: ProviderAnthropic, StatusCode: 529, Category: ErrOverloaded}
+	invalid := &APIError{Provider: ProviderAnthropic, StatusCode: 400, Category: ErrInvalidRequest}
+
+	tests := []struct {
+		name     string
+		err      error
+		options  []FallbackOption
+		fellBack bool
+	}{
+		{"overloaded", overloaded, nil, true},
+		{"rate limited", &APIError{StatusCode: 429, Category: ErrRateLimited}, nil, true},
+		{"server error", &APIError{StatusCode: 500, Category: ErrServerError}, nil, true},
+		{"timeout", context.DeadlineExceeded, nil, true},
+		{"invalid request", invalid, nil, false},
+		{"not configured", overloaded, []FallbackOption{WithFallbackOn(ErrRateLimited)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			var hooked []Provider
+			options := append([]FallbackOption{WithFallbackHook(func(ctx context.Context, target FallbackTarget, err error) {
+				hooked = append(hooked, target.Provider)
+			})}, tt.options...)
+
+			fallback := NewFallback([]FallbackTarget{
+				{Provider: ProviderAnthropic, LLM: failingGenerator(tt.err, &calls)},
+				{Provider: ProviderOpenAI, LLM: modelGenerator(), Model: "gpt-4o"},
+			}, options...)
+
+			client := NewClient(WithProvider(ProviderAnthropic), WithModel("claude-sonnet-4"))
+			client.RegisterProvider(ProviderAnthropic, fallback)
+
+			result, err := client.GenerateText(context.Background())
+			if calls != 1 {
+				t.Errorf("Expected the first target to be called once, got %d", calls)
+			}
+			if !tt.fellBack {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result.Provider != ProviderOpenAI || result.Model != "gpt-4o" || result.Text != "gpt-4o" {
+				t.Errorf("Expected the second target to serve the request, got %+v", result)
+			}
+			if len(hooked) != 1 || hooked[0] != ProviderAnthropic {
+				t.Errorf("Expected the hook to see the first target fail, got %v", hooked)
+			}
+
+			text, err := client.GetText(context.Background())
+			if err != nil || text != "gpt-4o" {
+				t.Errorf("Expected GetText to fall back, got %q, %v", text, err)
+			}
+		})
+	}
+}
+
+func TestFallbackAllTargetsFail(t *testing.T) {
+	var calls int
+	fallback := NewFallback([]FallbackTarget{
+		{Provider: ProviderAnthropic, LLM: failingGenerator(&APIError{StatusCode: 529, Category: ErrOverloaded}, &calls)},
+		{Provider: ProviderOpenAI, LLM: failingGenerator(&APIError{StatusCode: 429, Category: ErrRateLimited}, &calls)},
+	})
+
+	_, err := fallback.GenerateText(context.Background(), &Config{Model: "test-model"})
+	if !errors.Is(err, ErrAllTargetsFailed) || !errors.Is(err, ErrOverloaded) || !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected the errors of every target, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected both targets to be called, got %d", calls)
+	}
+}
+
+func TestFallbackAttemptTimeout(t *testing.T) {
+	slow := &MockProvider{
+		GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+
+	var hookErr error
+	fallback := NewFallback([]FallbackTarget{
+		{Provider: ProviderAnthropic, LLM: slow},
+		{Provider: ProviderOpenAI, LLM: modelGenerator(), Model: "gpt-4o"},
+	}, WithAttemptTimeout(10*time.Millisecond), WithFallbackHook(func(ctx context.Context, target FallbackTarget, err error) {
+		hookErr = err
+	}))
+
+	text, err := fallback.GetText(context.Background(), &Config{Model: "test-model"})
+	if err != nil || text != "gpt-4o" {
+		t.Fatalf("Expected the second target to answer, got %q, %v", text, err)
+	}
+	if !errors.Is(hookErr, ErrTimeout) {
+		t.Errorf("Expected the first target to time out, got %v", hookErr)
+	}
+
+	// A done context is not retried
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := fallback.GetText(ctx, &Config{Model: "test-model"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestFallbackGetObject(t *testing.T) {
+	var calls int
+	fallback := NewFallback([]FallbackTarget{
+		{Provider: ProviderAnthropic, LLM: failingGenerator(&APIError{StatusCode: 503, Category: ErrOverloaded}, &calls)},
+		{Provider: ProviderOpenAI, LLM: &MockProvider{
+			GetObjectFunc: func(ctx context.Context, config *Config, target interface{}) error {
+				target.(*struct{ Name string }).Name = config.Model
+				return nil
+			},
+		}, Model: "gpt-4o"},
+	})
+
+	client := NewClient(WithProvider(ProviderAnthropic), WithModel("test-model"))
+	client.RegisterProvider(ProviderAnthropic, fallback)
+
+	var person struct{ Name string }
+	if err := client.GetObject(context.Background(), &person); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if person.Name != "gpt-4o" {
+		t.Errorf("Expected the second target to fill the object, got %+v", person)
+	}
+}
+
+func TestFallbackStream(t *testing.T) {
+	overloaded := &APIError{Provider: ProviderAnthropic, Category: ErrOverloaded}
+	failing := &MockStreamer{
+		StreamTextFunc: func(ctx context.Context, config *Config) (*Stream, error) {
+			// The error arrives in the stream, after its metadata
+			return NewStream(&sliceReader{deltas: []Delta{{ID: "msg_1"}}, err: overloaded}), nil
+		},
+	}
+
+	var reader *sliceReader
+	working := &MockStreamer{
+		StreamTextFunc: func(ctx context.Context, config *Config) (*Stream, error) {
+			reader = &sliceReader{deltas: []Delta{{ID: "chatcmpl_1"}, {Text: "Hello, "}, {Text: "world!"}, {FinishReason: FinishReasonStop}}}
+			return NewStream(reader), nil
+		},
+	}
+
+	fallback := NewFallback([]FallbackTarget{
+		{Provider: ProviderAnthropic, LLM: failing},
+		{Provider: "no-streaming", LLM: &MockProvider{}},
+		{Provider: ProviderOpenAI, LLM: working, Model: "gpt-4o"},
+	})
+
+	client := NewClient(WithProvider(ProviderAnthropic), WithModel("test-model"))
+	client.RegisterProvider(ProviderAnthropic, fallback)
+
+	stream, err := client.StreamText(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var text string
+	for stream.Next() {
+		text += stream.Delta().Text
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	result := stream.Result()
+	if text != "Hello, world!" || result.Text != "Hello, world!" {
+		t.Errorf("Expected the whole response, got %q", text)
+	}
+	if result.Provider != ProviderOpenAI || result.Model != "gpt-4o" || result.ID != "chatcmpl_1" || result.FinishReason != FinishReasonStop {
+		t.Errorf("Expected the second target's metadata, got %+v", result)
+	}
+	if !reader.closed {
+		t.Errorf("Expected the stream to be closed")
+	}
+}
+
+func TestFallbackCostTracker(t *testing.T) {
+	var calls int
+	fallback := NewFallback([]FallbackTarget{
+		{Provider: ProviderAnthropic, LLM: failingGenerator(&APIError{StatusCode: 529, Category: ErrOverloaded}, &calls)},
+		{Provider: ProviderOpenAI, LLM: &MockGenerator{
+			GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+				return &Result{Text: "Hello!", Usage: Usage{InputTokens: 1000000, OutputTokens: 1000000}}, nil
+			},
+		}, Model: "gpt-4o"},
+	})
+
+	pricing := NewPricing()
+	pricing.Set(ProviderAnthropic, "test-model", Price{Input: 100, Output: 100})
+	pricing.Set(ProviderOpenAI, "gpt-4o", Price{Input: 1, Output: 2})
+	tracker := NewCostTracker(WithPricing(pricing))
+
+	client := NewClient(WithProvider(ProviderAnthropic), WithModel("test-model"))
+	client.RegisterProvider(ProviderAnthropic, fallback)
+	client.Use(tracker.Middleware())
+
+	result, err := client.GenerateText(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The request is priced by the target that served it, not the one requested
+	if !approxEqual(result.Cost, 3) {
+		t.Errorf("Expected a cost of 3, got %v", result.Cost)
+	}
+	if byModel := tracker.ByModel(); !approxEqual(byModel["gpt-4o"], 3) || len(byModel) != 1 {
+		t.Errorf("Unexpected costs by model: %v", byModel)
+	}
+}
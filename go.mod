@@ -1,3 +1,14 @@
 module github.com/gnfisher/go-ai-sdk
 
-go 1.22
\ No newline at end of file
+go 1.22
+
+require (
+	github.com/mattn/go-sqlite3 v1.14.22
+	github.com/pkoukk/tiktoken-go v0.1.8
+	github.com/pkoukk/tiktoken-go-loader v0.0.2
+)
+
+require (
+	github.com/dlclark/regexp2 v1.10.0 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+)
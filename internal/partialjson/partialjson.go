@@ -0,0 +1,330 @@
+// Package partialjson parses JSON documents that are still being streamed.
+package partialjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// state describes how much of a value was parsed
+type state int
+
+const (
+	// stateNone means no usable value was parsed
+	stateNone state = iota
+
+	// statePartial means the value was cut short but what was parsed is usable
+	statePartial
+
+	// stateComplete means the whole value was parsed
+	stateComplete
+)
+
+// Parse parses data, which may be a truncated JSON document, closing any unterminated
+// strings, arrays and objects. Keys, numbers and literals that may still be incomplete
+// are left out. Numbers are returned as json.Number. It reports false if no value could be parsed.
+func Parse(data []byte) (interface{}, bool) {
+	p := &parser{data: data}
+	p.skipSpace()
+
+	value, state := p.value()
+	return value, state != stateNone
+}
+
+// Elements returns the raw JSON of the elements of a top-level array that have been
+// parsed completely. It reports false if data does not start with an array.
+func Elements(data []byte) ([]json.RawMessage, bool) {
+	p := &parser{data: data}
+	p.skipSpace()
+
+	if p.eof() || p.peek() != '[' {
+		return nil, false
+	}
+	p.pos++
+
+	var elements []json.RawMessage
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return elements, true
+		}
+
+		switch p.peek() {
+		case ']':
+			return elements, true
+		case ',':
+			p.pos++
+			continue
+		}
+
+		start := p.pos
+		if _, state := p.value(); state != stateComplete {
+			return elements, true
+		}
+		elements = append(elements, json.RawMessage(data[start:p.pos]))
+	}
+}
+
+// parser reads values from a possibly truncated JSON document
+type parser struct {
+	data []byte
+	pos  int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *parser) peek() byte {
+	return p.data[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// value parses the value at the current position
+func (p *parser) value() (interface{}, state) {
+	if p.eof() {
+		return nil, stateNone
+	}
+
+	switch c := p.peek(); {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '"':
+		return p.string()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	case c == 't':
+		return p.literal("true", true)
+	case c == 'f':
+		return p.literal("false", false)
+	case c == 'n':
+		return p.literal("null", nil)
+	}
+
+	return nil, stateNone
+}
+
+func (p *parser) object() (interface{}, state) {
+	p.pos++
+	object := make(map[string]interface{})
+
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return object, statePartial
+		}
+
+		switch p.peek() {
+		case '}':
+			p.pos++
+			return object, stateComplete
+		case ',':
+			p.pos++
+			continue
+		case '"':
+		default:
+			return object, statePartial
+		}
+
+		key, keyState := p.string()
+		if keyState != stateComplete {
+			return object, statePartial
+		}
+
+		p.skipSpace()
+		if p.eof() || p.peek() != ':' {
+			return object, statePartial
+		}
+		p.pos++
+		p.skipSpace()
+
+		value, valueState := p.value()
+		if valueState != stateNone {
+			object[key.(string)] = value
+		}
+		if valueState != stateComplete {
+			return object, statePartial
+		}
+	}
+}
+
+func (p *parser) array() (interface{}, state) {
+	p.pos++
+	array := make([]interface{}, 0)
+
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return array, statePartial
+		}
+
+		switch p.peek() {
+		case ']':
+			p.pos++
+			return array, stateComplete
+		case ',':
+			p.pos++
+			continue
+		}
+
+		value, valueState := p.value()
+		if valueState != stateNone {
+			array = append(array, value)
+		}
+		if valueState != stateComplete {
+			return array, statePartial
+		}
+	}
+}
+
+func (p *parser) string() (interface{}, state) {
+	start := p.pos
+	for i := start + 1; i < len(p.data); i++ {
+		switch p.data[i] {
+		case '\\':
+			i++
+		case '"':
+			var s string
+			if err := json.Unmarshal(p.data[start:i+1], &s); err != nil {
+				return nil, stateNone
+			}
+			p.pos = i + 1
+			return s, stateComplete
+		}
+	}
+
+	p.pos = len(p.data)
+
+	// Drop a trailing incomplete character or escape sequence and close the string
+	raw := p.data[start:]
+	for i := len(raw) - 1; i > 0 && i >= len(raw)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(raw[i]) {
+			if !utf8.FullRune(raw[i:]) {
+				raw = raw[:i]
+			}
+			break
+		}
+	}
+
+	for trim := 0; trim <= len(`\uXXXX`) && trim < len(raw); trim++ {
+		var s string
+		closed := append(append([]byte(nil), raw[:len(raw)-trim]...), '"')
+		if err := json.Unmarshal(closed, &s); err == nil {
+			return s, statePartial
+		}
+	}
+
+	return nil, stateNone
+}
+
+func (p *parser) number() (interface{}, state) {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if (c < '0' || c > '9') && c != '-' && c != '+' && c != '.' && c != 'e' && c != 'E' {
+			break
+		}
+		p.pos++
+	}
+
+	// A number at the end of the data may still continue
+	if p.eof() {
+		return nil, stateNone
+	}
+
+	text := p.data[start:p.pos]
+	if !json.Valid(text) {
+		return nil, stateNone
+	}
+	return json.Number(text), stateComplete
+}
+
+func (p *parser) literal(name string, value interface{}) (interface{}, state) {
+	rest := p.data[p.pos:]
+	if len(rest) < len(name) {
+		// A literal that was cut short has no usable value yet
+		if string(rest) == name[:len(rest)] {
+			p.pos = len(p.data)
+		}
+		return nil, stateNone
+	}
+
+	if string(rest[:len(name)]) != name {
+		return nil, stateNone
+	}
+
+	p.pos += len(name)
+	return value, stateComplete
+}
+
+// Unwrapper removes the {"<property>": ...} wrapper from a JSON document as it is streamed
+type Unwrapper struct {
+	prefix  []byte
+	matched int
+	pending []byte
+}
+
+// NewUnwrapper creates an Unwrapper for the given property
+func NewUnwrapper(property string) *Unwrapper {
+	prefix, _ := json.Marshal(property)
+	return &Unwrapper{prefix: append(append([]byte{'{'}, prefix...), ':')}
+}
+
+// Write adds the next fragment of the wrapped document and returns the next
+// fragment of the wrapped value. Trailing closing braces are held back until
+// it is clear they belong to the value.
+func (u *Unwrapper) Write(text string) (string, error) {
+	data := []byte(text)
+
+	for len(data) > 0 && u.matched < len(u.prefix) {
+		c := data[0]
+		data = data[1:]
+
+		switch {
+		case c == u.prefix[u.matched]:
+			u.matched++
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			return "", fmt.Errorf("unexpected %q before wrapped value", c)
+		}
+	}
+
+	u.pending = append(u.pending, data...)
+
+	held := len(u.pending)
+	for held > 0 {
+		switch u.pending[held-1] {
+		case '}', ' ', '\t', '\n', '\r':
+			held--
+			continue
+		}
+		break
+	}
+
+	out := string(u.pending[:held])
+	u.pending = append(u.pending[:0], u.pending[held:]...)
+	return out, nil
+}
+
+// Close returns the rest of the wrapped value, dropping the wrapper's closing brace
+func (u *Unwrapper) Close() string {
+	for i := len(u.pending) - 1; i >= 0; i-- {
+		if u.pending[i] == '}' {
+			return string(u.pending[:i])
+		}
+	}
+	return string(u.pending)
+}
@@ -0,0 +1,132 @@
+package partialjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{``, ``, false},
+		{`{`, `{}`, true},
+		{`{"na`, `{}`, true},
+		{`{"name"`, `{}`, true},
+		{`{"name":`, `{}`, true},
+		{`{"name":"Ad`, `{"name":"Ad"}`, true},
+		{`{"name":"Ada","tags":["a","b`, `{"name":"Ada","tags":["a","b"]}`, true},
+		{`{"age":3`, `{}`, true},
+		{`{"age":36,`, `{"age":36}`, true},
+		{`{"score":1.`, `{}`, true},
+		{`{"ok":tr`, `{}`, true},
+		{`{"ok":true`, `{"ok":true}`, true},
+		{`{"ok":null,"n":[1,2`, `{"n":[1],"ok":null}`, true},
+		{`[{"a":1},{"a":`, `[{"a":1},{}]`, true},
+		{`"line\`, `"line"`, true},
+		{`"snow\u26`, `"snow"`, true},
+		{`"snow☃`, `"snow☃"`, true},
+		{"\"caf\xc3", `"caf"`, true},
+		{`  {"done":{}}  `, `{"done":{}}`, true},
+		{`tru`, ``, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			value, ok := Parse([]byte(tt.input))
+			if ok != tt.ok {
+				t.Fatalf("Parse(%q) ok = %v, want %v", tt.input, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+
+			got, err := json.Marshal(value)
+			if err != nil {
+				t.Fatalf("failed to marshal value: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseNumbers(t *testing.T) {
+	value, _ := Parse([]byte(`{"n":-12.5e3,"m":4`))
+	object := value.(map[string]interface{})
+	if n := object["n"]; n != json.Number("-12.5e3") {
+		t.Errorf("Expected number -12.5e3, got %v", n)
+	}
+	if _, ok := object["m"]; ok {
+		t.Errorf("Expected number that may continue to be left out, got %v", object["m"])
+	}
+}
+
+func TestElements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+		ok       bool
+	}{
+		{`{"a":1}`, nil, false},
+		{`[`, nil, true},
+		{`[{"a":1},{"a":`, []string{`{"a":1}`}, true},
+		{`[1, 2`, []string{`1`}, true},
+		{`[1, 2]`, []string{`1`, `2`}, true},
+		{`["x", "y"`, []string{`"x"`, `"y"`}, true},
+		{`[[1], [2, 3`, []string{`[1]`}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			elements, ok := Elements([]byte(tt.input))
+			if ok != tt.ok {
+				t.Fatalf("Elements(%q) ok = %v, want %v", tt.input, ok, tt.ok)
+			}
+
+			var got []string
+			for _, element := range elements {
+				got = append(got, string(element))
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Elements(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnwrapper(t *testing.T) {
+	document := `{ "value" : [{"a":1},{"b":{}}] }`
+
+	// Feed the document one byte at a time
+	unwrapper := NewUnwrapper("value")
+	var out strings.Builder
+	for i := 0; i < len(document); i++ {
+		text, err := unwrapper.Write(document[i : i+1])
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		out.WriteString(text)
+
+		// Closing braces are held back until they are known to belong to the value
+		if strings.HasSuffix(out.String(), "}") && !strings.HasSuffix(document[:i+1], "}") {
+			t.Errorf("Unexpected trailing brace in %q", out.String())
+		}
+	}
+	out.WriteString(unwrapper.Close())
+
+	if got := strings.TrimSpace(out.String()); got != `[{"a":1},{"b":{}}]` {
+		t.Errorf("Unexpected unwrapped value: %s", got)
+	}
+
+	// Anything other than the wrapper is rejected
+	unwrapper = NewUnwrapper("value")
+	if _, err := unwrapper.Write(`{"other":1}`); err == nil {
+		t.Errorf("Expected error for unexpected property")
+	}
+}
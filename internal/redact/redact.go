@@ -0,0 +1,42 @@
+// Package redact removes API keys and other credentials from values before they are logged.
+package redact
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Placeholder replaces redacted values
+const Placeholder = "[REDACTED]"
+
+// sensitiveHeaders are the headers that carry credentials
+var sensitiveHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"x-api-key":           true,
+	"api-key":             true,
+	"cookie":              true,
+	"set-cookie":          true,
+}
+
+// secretPattern matches API keys and bearer tokens in free text
+var secretPattern = regexp.MustCompile(`\b(sk-[A-Za-z0-9_\-]{8,}|Bearer\s+[A-Za-z0-9_\-\.=]+)`)
+
+// Header returns a copy of header with the values of credential headers replaced
+func Header(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		if sensitiveHeaders[strings.ToLower(name)] {
+			redacted[name] = []string{Placeholder}
+			continue
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
+
+// String replaces anything that looks like an API key or bearer token in s
+func String(s string) string {
+	return secretPattern.ReplaceAllString(s, Placeholder)
+}
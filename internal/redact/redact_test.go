@@ -0,0 +1,43 @@
+package redact
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer sk-secret")
+	header.Set("X-Api-Key", "sk-ant-secret")
+	header.Set("Content-Type", "application/json")
+
+	redacted := Header(header)
+	if redacted.Get("Authorization") != Placeholder || redacted.Get("X-Api-Key") != Placeholder {
+		t.Errorf("Expected credentials to be redacted, got %v", redacted)
+	}
+	if redacted.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected other headers to be kept, got %v", redacted)
+	}
+	if header.Get("Authorization") != "Bearer sk-secret" {
+		t.Errorf("Expected the original header to be unchanged")
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"my key is sk-proj-abcdefgh1234", "my key is [REDACTED]"},
+		{"x-api-key: sk-ant-api03-abcdefghij", "x-api-key: [REDACTED]"},
+		{"Authorization: Bearer abc.def-123", "Authorization: [REDACTED]"},
+		{"ask-me anything", "ask-me anything"},
+		{"no secrets here", "no secrets here"},
+	}
+
+	for _, tt := range tests {
+		if got := String(tt.input); got != tt.expected {
+			t.Errorf("String(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
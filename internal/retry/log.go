@@ -0,0 +1,39 @@
+package retry
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gnfisher/go-ai-sdk/internal/redact"
+)
+
+// LogResponse logs the outcome of a request at debug level, with credential headers redacted
+func LogResponse(ctx context.Context, logger *slog.Logger, req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	if logger == nil || !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{slog.Duration("duration", duration)}
+	if req != nil {
+		attrs = append(attrs,
+			slog.String("method", req.Method),
+			slog.String("url", req.URL.String()),
+			slog.Any("request_headers", redact.Header(req.Header)),
+		)
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", redact.String(err.Error())))
+	} else {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if id := resp.Header.Get("x-request-id"); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		} else if id := resp.Header.Get("request-id"); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+	}
+
+	logger.LogAttrs(ctx, slog.LevelDebug, "provider response", attrs...)
+}
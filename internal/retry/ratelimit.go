@@ -0,0 +1,60 @@
+package retry
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+// rateLimitHeaders names the limit, remaining and reset headers for one kind of rate limit
+type rateLimitHeaders struct {
+	limit, remaining, reset string
+}
+
+var (
+	requestHeaders = []rateLimitHeaders{
+		{"x-ratelimit-limit-requests", "x-ratelimit-remaining-requests", "x-ratelimit-reset-requests"},
+		{"anthropic-ratelimit-requests-limit", "anthropic-ratelimit-requests-remaining", "anthropic-ratelimit-requests-reset"},
+	}
+
+	// Anthropic reports input and output token limits separately as well as combined;
+	// the first set of headers present is used
+	tokenHeaders = []rateLimitHeaders{
+		{"x-ratelimit-limit-tokens", "x-ratelimit-remaining-tokens", "x-ratelimit-reset-tokens"},
+		{"anthropic-ratelimit-tokens-limit", "anthropic-ratelimit-tokens-remaining", "anthropic-ratelimit-tokens-reset"},
+		{"anthropic-ratelimit-input-tokens-limit", "anthropic-ratelimit-input-tokens-remaining", "anthropic-ratelimit-input-tokens-reset"},
+	}
+)
+
+// RateLimits returns the rate limit status reported in the response headers,
+// or false if the response reports no limits
+func RateLimits(header http.Header, now time.Time) (ai.RateLimitStatus, bool) {
+	var status ai.RateLimitStatus
+	status.RequestsLimit, status.RequestsRemaining, status.RequestsReset = readRateLimit(header, requestHeaders, now)
+	status.TokensLimit, status.TokensRemaining, status.TokensReset = readRateLimit(header, tokenHeaders, now)
+	return status, status.RequestsLimit > 0 || status.TokensLimit > 0
+}
+
+// readRateLimit reads the first set of headers holding a limit
+func readRateLimit(header http.Header, candidates []rateLimitHeaders, now time.Time) (int, int, time.Time) {
+	for _, names := range candidates {
+		limit, err := strconv.Atoi(header.Get(names.limit))
+		if err != nil || limit <= 0 {
+			continue
+		}
+
+		remaining, err := strconv.Atoi(header.Get(names.remaining))
+		if err != nil {
+			remaining = limit
+		}
+
+		var reset time.Time
+		if wait, ok := parseReset(header.Get(names.reset), now); ok {
+			reset = now.Add(wait)
+		}
+		return limit, remaining, reset
+	}
+	return 0, 0, time.Time{}
+}
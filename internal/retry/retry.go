@@ -0,0 +1,203 @@
+// Package retry sends HTTP requests to provider APIs, retrying transient failures.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gnfisher/go-ai-sdk"
+	"github.com/gnfisher/go-ai-sdk/internal/redact"
+)
+
+// rateLimitResets pairs the remaining-quota headers sent by providers with the
+// header holding the time at which that quota resets
+var rateLimitResets = [][2]string{
+	{"x-ratelimit-remaining-requests", "x-ratelimit-reset-requests"},
+	{"x-ratelimit-remaining-tokens", "x-ratelimit-reset-tokens"},
+	{"anthropic-ratelimit-requests-remaining", "anthropic-ratelimit-requests-reset"},
+	{"anthropic-ratelimit-tokens-remaining", "anthropic-ratelimit-tokens-reset"},
+	{"anthropic-ratelimit-input-tokens-remaining", "anthropic-ratelimit-input-tokens-reset"},
+	{"anthropic-ratelimit-output-tokens-remaining", "anthropic-ratelimit-output-tokens-reset"},
+}
+
+// Do sends the request built by newRequest, retrying transient failures according to
+// policy. newRequest is called for every attempt, since a request body can only be read once.
+// shouldRetry decides whether a response is retried, so that providers can look at the error
+// it holds; nil uses ShouldRetry. Each retry is logged to logger, if set. The last response is
+// returned whatever its status code; the caller must close its body.
+func Do(ctx context.Context, client *http.Client, policy ai.RetryPolicy, logger *slog.Logger, shouldRetry func(*http.Response) bool, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	if shouldRetry == nil {
+		shouldRetry = ShouldRetry
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+
+		var retry, hinted bool
+		var delay time.Duration
+		if err != nil {
+			retry = ctx.Err() == nil && RetryableError(err)
+		} else {
+			retry = shouldRetry(resp)
+			delay, hinted = After(resp.Header, time.Now())
+		}
+
+		if !retry || attempt >= policy.MaxAttempts {
+			return resp, err
+		}
+
+		if !hinted {
+			delay = policy.Delay(attempt)
+		} else if maxDelay := maxDelay(policy); delay > maxDelay {
+			delay = maxDelay
+		}
+
+		// Give up now if the context will expire before the next attempt
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+
+		if logger != nil {
+			attrs := []slog.Attr{slog.Int("attempt", attempt), slog.Duration("delay", delay)}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", redact.String(err.Error())))
+			} else {
+				attrs = append(attrs, slog.Int("status", resp.StatusCode))
+			}
+			logger.LogAttrs(ctx, slog.LevelWarn, "retrying request", attrs...)
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// maxDelay returns the longest the policy waits between attempts, including waits asked for by the server
+func maxDelay(policy ai.RetryPolicy) time.Duration {
+	if policy.MaxDelay <= 0 {
+		return ai.DefaultRetryMaxDelay
+	}
+	return policy.MaxDelay
+}
+
+// RetryableStatus reports whether a response with the given status code may succeed if retried
+func RetryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= http.StatusInternalServerError
+}
+
+// ShouldRetry reports whether a request should be retried after the given response.
+// Providers can override the status code with the x-should-retry header.
+func ShouldRetry(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusOK {
+		return false
+	}
+
+	switch resp.Header.Get("x-should-retry") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	return RetryableStatus(resp.StatusCode)
+}
+
+// RetryableError reports whether a request that failed with err may succeed if retried,
+// such as after a timeout or a dropped connection
+func RetryableError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// url.Error itself implements net.Error, so look at what it wraps
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// After returns how long the server asked the client to wait before retrying, from the
+// retry-after-ms and Retry-After headers or, failing those, the reset time of an exhausted rate limit
+func After(header http.Header, now time.Time) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+			return time.Duration(seconds * float64(time.Second)), true
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return nonNegative(date.Sub(now)), true
+		}
+	}
+
+	var wait time.Duration
+	var found bool
+	for _, pair := range rateLimitResets {
+		if header.Get(pair[0]) != "0" {
+			continue
+		}
+		if reset, ok := parseReset(header.Get(pair[1]), now); ok {
+			found = true
+			if reset > wait {
+				wait = reset
+			}
+		}
+	}
+	return wait, found
+}
+
+// parseReset parses a rate limit reset header, which OpenAI sends as a duration
+// such as "6m0s" and Anthropic as an RFC 3339 timestamp
+func parseReset(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return nonNegative(duration), true
+	}
+	if reset, err := time.Parse(time.RFC3339, value); err == nil {
+		return nonNegative(reset.Sub(now)), true
+	}
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,286 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+var fastPolicy = ai.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// statusServer replies with the given status codes in order, then with 200
+func statusServer(calls *int32, headers http.Header, statuses ...int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "request" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		n := int(atomic.AddInt32(calls, 1))
+		if n <= len(statuses) {
+			for name, values := range headers {
+				w.Header()[name] = values
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+}
+
+func newRequest(ctx context.Context, url string) func() (*http.Request, error) {
+	return func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader("request"))
+	}
+}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name           string
+		statuses       []int
+		headers        http.Header
+		policy         ai.RetryPolicy
+		expectedStatus int
+		expectedCalls  int32
+	}{
+		{"success", nil, nil, fastPolicy, http.StatusOK, 1},
+		{"rate limited", []int{429, 429}, nil, fastPolicy, http.StatusOK, 3},
+		{"overloaded", []int{529}, nil, fastPolicy, http.StatusOK, 2},
+		{"attempts exhausted", []int{500, 500, 500}, nil, fastPolicy, http.StatusInternalServerError, 3},
+		{"not retryable", []int{400}, nil, fastPolicy, http.StatusBadRequest, 1},
+		{"no policy", []int{503}, nil, ai.RetryPolicy{}, http.StatusServiceUnavailable, 1},
+		{"should retry header", []int{400}, http.Header{"X-Should-Retry": {"true"}}, fastPolicy, http.StatusOK, 2},
+		{"should not retry header", []int{503}, http.Header{"X-Should-Retry": {"false"}}, fastPolicy, http.StatusServiceUnavailable, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := statusServer(&calls, tt.headers, tt.statuses...)
+			defer server.Close()
+
+			resp, err := Do(context.Background(), server.Client(), tt.policy, nil, nil, newRequest(context.Background(), server.URL))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("Expected %d calls, got %d", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestDoHonorsRetryAfter(t *testing.T) {
+	var calls int32
+	server := statusServer(&calls, http.Header{"Retry-After-Ms": {"50"}}, 429)
+	defer server.Close()
+
+	policy := ai.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+
+	start := time.Now()
+	resp, err := Do(context.Background(), server.Client(), policy, nil, nil, newRequest(context.Background(), server.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected to wait for the retry-after-ms delay, waited %v", elapsed)
+	}
+
+	// The server's delay is capped by the policy's MaxDelay
+	server = statusServer(&calls, http.Header{"Retry-After": {"60"}}, 429)
+	defer server.Close()
+
+	start = time.Now()
+	resp, err = Do(context.Background(), server.Client(), fastPolicy, nil, nil, newRequest(context.Background(), server.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if elapsed := time.Since(start); resp.StatusCode != http.StatusOK || elapsed > 5*time.Second {
+		t.Errorf("Expected a retry after at most MaxDelay, got %d after %v", resp.StatusCode, elapsed)
+	}
+}
+
+func TestDoRespectsDeadline(t *testing.T) {
+	var calls int32
+	server := statusServer(&calls, http.Header{"Retry-After": {"10"}}, 429)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// The server asks for a longer wait than the deadline allows, so the 429 is returned
+	resp, err := Do(ctx, server.Client(), ai.RetryPolicy{MaxAttempts: 3, MaxDelay: time.Minute}, nil, nil, newRequest(ctx, server.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests || calls != 1 {
+		t.Errorf("Expected a single 429 response, got %d after %d calls", resp.StatusCode, calls)
+	}
+
+	// Cancelling while waiting stops the retries
+	server = statusServer(&calls, nil, 503, 503)
+	defer server.Close()
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err = Do(ctx, server.Client(), ai.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second}, nil, nil, newRequest(ctx, server.URL))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDoRetriesNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	var attempts int
+	_, err := Do(context.Background(), http.DefaultClient, fastPolicy, nil, nil, func() (*http.Request, error) {
+		attempts++
+		return http.NewRequest(http.MethodPost, url, nil)
+	})
+	if err == nil {
+		t.Fatalf("Expected an error from a closed server")
+	}
+	if attempts != 3 {
+		t.Errorf("Expected connection errors to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestRetryableError(t *testing.T) {
+	if RetryableError(context.Canceled) {
+		t.Errorf("Expected context.Canceled not to be retryable")
+	}
+	if !RetryableError(io.ErrUnexpectedEOF) {
+		t.Errorf("Expected io.ErrUnexpectedEOF to be retryable")
+	}
+	if RetryableError(errors.New("unsupported protocol scheme")) {
+		t.Errorf("Expected other errors not to be retryable")
+	}
+}
+
+func TestAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+		ok       bool
+	}{
+		{"none", http.Header{}, 0, false},
+		{"retry-after-ms", http.Header{"Retry-After-Ms": {"1500"}, "Retry-After": {"9"}}, 1500 * time.Millisecond, true},
+		{"retry-after seconds", http.Header{"Retry-After": {"2"}}, 2 * time.Second, true},
+		{"retry-after date", http.Header{"Retry-After": {"Wed, 01 May 2024 12:00:30 GMT"}}, 30 * time.Second, true},
+		{"openai reset", http.Header{
+			"X-Ratelimit-Remaining-Requests": {"0"},
+			"X-Ratelimit-Reset-Requests":     {"1m30s"},
+			"X-Ratelimit-Remaining-Tokens":   {"100"},
+			"X-Ratelimit-Reset-Tokens":       {"5m0s"},
+		}, 90 * time.Second, true},
+		{"anthropic reset", http.Header{
+			"Anthropic-Ratelimit-Tokens-Remaining": {"0"},
+			"Anthropic-Ratelimit-Tokens-Reset":     {"2024-05-01T12:00:05Z"},
+		}, 5 * time.Second, true},
+		{"quota left", http.Header{
+			"X-Ratelimit-Remaining-Requests": {"3"},
+			"X-Ratelimit-Reset-Requests":     {"1s"},
+		}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := After(tt.header, now)
+			if delay != tt.expected || ok != tt.ok {
+				t.Errorf("After() = %v, %v, want %v, %v", delay, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	header := http.Header{}
+	header.Set("x-ratelimit-limit-requests", "500")
+	header.Set("x-ratelimit-remaining-requests", "499")
+	header.Set("x-ratelimit-reset-requests", "120ms")
+	header.Set("x-ratelimit-limit-tokens", "30000")
+	header.Set("x-ratelimit-remaining-tokens", "0")
+	header.Set("x-ratelimit-reset-tokens", "6m0s")
+
+	status, ok := RateLimits(header, now)
+	if !ok {
+		t.Fatalf("Expected rate limits")
+	}
+	if status.RequestsLimit != 500 || status.RequestsRemaining != 499 || !status.RequestsReset.Equal(now.Add(120*time.Millisecond)) {
+		t.Errorf("Unexpected request limits: %+v", status)
+	}
+	if status.TokensLimit != 30000 || status.TokensRemaining != 0 || !status.TokensReset.Equal(now.Add(6*time.Minute)) {
+		t.Errorf("Unexpected token limits: %+v", status)
+	}
+
+	header = http.Header{}
+	header.Set("anthropic-ratelimit-requests-limit", "50")
+	header.Set("anthropic-ratelimit-requests-remaining", "10")
+	header.Set("anthropic-ratelimit-requests-reset", "2025-01-01T12:00:30Z")
+	header.Set("anthropic-ratelimit-input-tokens-limit", "40000")
+	header.Set("anthropic-ratelimit-input-tokens-remaining", "39000")
+
+	status, ok = RateLimits(header, now)
+	if !ok {
+		t.Fatalf("Expected rate limits")
+	}
+	if status.RequestsLimit != 50 || status.RequestsRemaining != 10 || !status.RequestsReset.Equal(now.Add(30*time.Second)) {
+		t.Errorf("Unexpected request limits: %+v", status)
+	}
+	if status.TokensLimit != 40000 || status.TokensRemaining != 39000 || !status.TokensReset.IsZero() {
+		t.Errorf("Unexpected token limits: %+v", status)
+	}
+
+	if _, ok := RateLimits(http.Header{}, now); ok {
+		t.Errorf("Expected no rate limits")
+	}
+}
+
+func TestDoLogsRetries(t *testing.T) {
+	var calls int32
+	server := statusServer(&calls, nil, 503)
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	resp, err := Do(context.Background(), server.Client(), fastPolicy, logger, nil, newRequest(context.Background(), server.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if !strings.Contains(buf.String(), `level=WARN msg="retrying request" attempt=1`) || !strings.Contains(buf.String(), "status=503") {
+		t.Errorf("Expected the retry to be logged, got %s", buf.String())
+	}
+}
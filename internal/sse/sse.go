@@ -0,0 +1,68 @@
+// Package sse reads server-sent events from an HTTP response body.
+package sse
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Event represents a single server-sent event
+type Event struct {
+	Event string
+	Data  string
+}
+
+// Reader reads events from a server-sent event stream
+type Reader struct {
+	reader *bufio.Reader
+}
+
+// NewReader creates a new Reader reading from r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{reader: bufio.NewReader(r)}
+}
+
+// Next returns the next event in the stream. It returns io.EOF once the stream ends.
+func (r *Reader) Next() (Event, error) {
+	var event Event
+	var data []string
+
+	for {
+		line, err := r.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			// Dispatch a final event that was not terminated by a blank line
+			if err == io.EOF && len(data) > 0 {
+				event.Data = strings.Join(data, "\n")
+				return event, nil
+			}
+			return Event{}, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		// A blank line dispatches the event
+		if line == "" {
+			if len(data) == 0 && event.Event == "" {
+				continue
+			}
+			event.Data = strings.Join(data, "\n")
+			return event, nil
+		}
+
+		// Lines starting with a colon are comments
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package sse
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	stream := ": comment\n" +
+		"event: message_start\n" +
+		"data: {\"a\":1}\n" +
+		"\n" +
+		"data: line one\r\n" +
+		"data: line two\r\n" +
+		"\r\n" +
+		"data: [DONE]"
+
+	reader := NewReader(strings.NewReader(stream))
+
+	expected := []Event{
+		{Event: "message_start", Data: `{"a":1}`},
+		{Data: "line one\nline two"},
+		{Data: "[DONE]"},
+	}
+
+	for i, want := range expected {
+		got, err := reader.Next()
+		if err != nil {
+			t.Fatalf("event %d: unexpected error %v", i, err)
+		}
+		if got != want {
+			t.Errorf("event %d = %+v, want %+v", i, got, want)
+		}
+	}
+
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
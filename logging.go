@@ -0,0 +1,112 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/gnfisher/go-ai-sdk/internal/redact"
+)
+
+// WithLogger sets the logger for requests. The client logs each request at the level set by
+// WithLogLevel, or at error level if it fails; providers log HTTP details at debug level and
+// retries at warn level. API keys and credential headers are redacted.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) {
+		c.Logger = logger
+	}
+}
+
+// WithLogLevel sets the level at which successful requests are logged (defaults to slog.LevelInfo)
+func WithLogLevel(level slog.Level) Option {
+	return func(c *Config) {
+		c.LogLevel = level
+	}
+}
+
+// WithContentLogging makes the client log the full prompt and response of each request.
+// Prompts often hold personal data, so enable it with care.
+func WithContentLogging() Option {
+	return func(c *Config) {
+		c.LogContent = true
+	}
+}
+
+// logRequests is the innermost middleware, logging requests as they are sent to the provider
+func logRequests(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (*Response, error) {
+		logger := req.Config.Logger
+		if logger == nil {
+			return next(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := next(ctx, req)
+		if err == nil && resp.Stream != nil {
+			resp.Stream.OnFinish(func(result *Result, err error) {
+				logRequest(ctx, logger, req, result, err, time.Since(start))
+			})
+			return resp, nil
+		}
+
+		var result *Result
+		if resp != nil {
+			result = resp.Result
+		}
+		logRequest(ctx, logger, req, result, err, time.Since(start))
+		return resp, err
+	}
+}
+
+// logRequest logs a finished request
+func logRequest(ctx context.Context, logger *slog.Logger, req *Request, result *Result, err error, duration time.Duration) {
+	config := req.Config
+	level := config.LogLevel
+
+	attrs := []slog.Attr{
+		slog.String("kind", string(req.Kind)),
+		slog.String("provider", string(config.Provider)),
+		slog.String("model", config.Model),
+		slog.Int("messages", len(config.Messages)),
+		slog.Duration("duration", duration),
+	}
+
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", redact.String(err.Error())))
+	}
+
+	if result != nil {
+		if result.Model != "" {
+			attrs = append(attrs, slog.String("response_model", result.Model))
+		}
+		if result.ID != "" {
+			attrs = append(attrs, slog.String("response_id", result.ID))
+		}
+		if result.FinishReason != "" {
+			attrs = append(attrs, slog.String("finish_reason", string(result.FinishReason)))
+		}
+		if len(result.ToolCalls) > 0 {
+			attrs = append(attrs, slog.Int("tool_calls", len(result.ToolCalls)))
+		}
+		attrs = append(attrs,
+			slog.Int("input_tokens", result.Usage.InputTokens),
+			slog.Int("output_tokens", result.Usage.OutputTokens),
+		)
+		if result.Cached {
+			attrs = append(attrs, slog.Bool("cached", true))
+		}
+	}
+
+	if config.LogContent {
+		if prompt, err := json.Marshal(config.Messages); err == nil {
+			attrs = append(attrs, slog.String("prompt", redact.String(string(prompt))))
+		}
+		if result != nil {
+			attrs = append(attrs, slog.String("response", redact.String(result.Text)))
+		}
+	}
+
+	logger.LogAttrs(ctx, level, "llm request", attrs...)
+}
@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"), WithLogger(logger))
+	client.RegisterProvider(ProviderOpenAI, &MockGenerator{
+		GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+			if config.Temperature == 0 {
+				return nil, errors.New("invalid key sk-proj-abcdefghijkl")
+			}
+			return &Result{Text: "Hello!", FinishReason: FinishReasonStop, Usage: Usage{InputTokens: 5, OutputTokens: 2}, Model: "test-model-001"}, nil
+		},
+	})
+
+	if _, err := client.GenerateText(context.Background(), WithMessages(UserMessage("Hello"))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	line := buf.String()
+	for _, expected := range []string{"level=INFO", `msg="llm request"`, "kind=generate", "provider=openai", "model=test-model", "messages=1", "response_model=test-model-001", "finish_reason=stop", "input_tokens=5", "output_tokens=2", "duration="} {
+		if !strings.Contains(line, expected) {
+			t.Errorf("Expected log to contain %s, got %s", expected, line)
+		}
+	}
+	if strings.Contains(line, "Hello") {
+		t.Errorf("Expected content not to be logged by default, got %s", line)
+	}
+
+	// Failures are logged at error level with secrets redacted
+	buf.Reset()
+	if _, err := client.GenerateText(context.Background(), WithTemperature(0)); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if line := buf.String(); !strings.Contains(line, "level=ERROR") || !strings.Contains(line, `error="invalid key [REDACTED]"`) {
+		t.Errorf("Expected a redacted error log, got %s", line)
+	}
+}
+
+func TestLoggingLevelAndContent(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockStreamer{
+		StreamTextFunc: func(ctx context.Context, config *Config) (*Stream, error) {
+			return NewStream(&sliceReader{deltas: []Delta{{Text: "Your key is sk-ant-abcdefghijkl"}}}), nil
+		},
+	})
+
+	stream, err := client.StreamText(context.Background(),
+		WithLogger(logger),
+		WithLogLevel(slog.LevelDebug),
+		WithContentLogging(),
+		WithMessages(UserMessage("What is my key?")),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected streams to be logged once they finish, got %s", buf.String())
+	}
+	for stream.Next() {
+	}
+
+	line := buf.String()
+	if !strings.Contains(line, "level=DEBUG") || !strings.Contains(line, "kind=stream") {
+		t.Errorf("Expected a debug log for the stream, got %s", line)
+	}
+	if !strings.Contains(line, "What is my key?") || !strings.Contains(line, `response="Your key is [REDACTED]"`) {
+		t.Errorf("Expected the redacted prompt and response, got %s", line)
+	}
+}
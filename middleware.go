@@ -0,0 +1,188 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RequestKind identifies the client method that made a request
+type RequestKind string
+
+const (
+	// RequestText is a call to GetText
+	RequestText RequestKind = "text"
+
+	// RequestGenerate is a call to GenerateText or a single step of Run
+	RequestGenerate RequestKind = "generate"
+
+	// RequestObject is a single attempt of GetObject, Object or GenerateObject
+	RequestObject RequestKind = "object"
+
+	// RequestStream is a call to StreamText
+	RequestStream RequestKind = "stream"
+
+	// RequestObjectStream is a call to StreamObject or StreamElements
+	RequestObjectStream RequestKind = "object_stream"
+)
+
+// Request is a call to a provider passing through the client's middleware
+type Request struct {
+	Kind RequestKind
+
+	// Config is the merged configuration for the call. Middleware may modify it
+	// before calling the next handler, including to select a different provider.
+	Config *Config
+
+	// Target is the value structured responses are decoded into, for object requests
+	Target interface{}
+}
+
+// Response is the outcome of a Request. Text and object requests return a Result;
+// stream requests return a Stream, whose result is available through OnFinish.
+type Response struct {
+	Result *Result
+	Stream *Stream
+}
+
+// Handler sends a Request to a provider
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Middleware wraps a Handler to observe or change the requests it sends and the responses it returns
+type Middleware func(next Handler) Handler
+
+// Use adds middleware to the client, wrapping every call the client makes to a provider.
+// Middleware added first is outermost: it sees each request first and each response last.
+// Use is not safe to call concurrently with requests.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// handle sends a request through the client's middleware to its provider.
+// Requests are logged innermost, as they are sent after any changes by middleware.
+func (c *Client) handle(ctx context.Context, req *Request) (*Response, error) {
+	handler := logRequests(c.send)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		handler = c.middleware[i](handler)
+	}
+	return handler(ctx, req)
+}
+
+// send is the innermost handler, calling the provider selected by the request's config
+func (c *Client) send(ctx context.Context, req *Request) (*Response, error) {
+	provider, err := c.provider(req.Config)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkModel(ctx, req); err != nil {
+		return nil, err
+	}
+
+	switch req.Kind {
+	case RequestText, RequestGenerate:
+		result, err := generateText(ctx, provider, req.Config)
+		if err != nil {
+			return nil, err
+		}
+		return &Response{Result: result}, nil
+
+	case RequestObject:
+		// The result is returned along with an *InvalidObjectError for repair
+		result, err := getObject(ctx, provider, req.Config, req.Target)
+		return &Response{Result: result}, err
+
+	case RequestStream:
+		streamer, ok := provider.(StreamingProvider)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrStreamingNotSupported, req.Config.Provider)
+		}
+		stream, err := streamer.StreamText(ctx, req.Config)
+		if err != nil {
+			return nil, err
+		}
+		return &Response{Stream: stream}, nil
+
+	case RequestObjectStream:
+		streamer, ok := provider.(ObjectStreamer)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrStreamingNotSupported, req.Config.Provider)
+		}
+		stream, err := streamer.StreamObject(ctx, req.Config, req.Target)
+		if err != nil {
+			return nil, err
+		}
+		return &Response{Stream: stream}, nil
+	}
+
+	return nil, fmt.Errorf("unknown request kind: %s", req.Kind)
+}
+
+// Observe returns middleware that calls fn once each request finishes, with its result,
+// error and duration. For streams, fn is called when the stream ends.
+func Observe(fn func(ctx context.Context, req *Request, result *Result, err error, duration time.Duration)) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			if err == nil && resp.Stream != nil {
+				resp.Stream.OnFinish(func(result *Result, err error) {
+					fn(ctx, req, result, err, time.Since(start))
+				})
+				return resp, nil
+			}
+
+			var result *Result
+			if resp != nil {
+				result = resp.Result
+			}
+			fn(ctx, req, result, err, time.Since(start))
+			return resp, err
+		}
+	}
+}
+
+// Timeout returns middleware that limits each request to the given duration.
+// For streams, the limit covers reading the whole stream.
+func Timeout(timeout time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			resp, err := next(ctx, req)
+			if err == nil && resp.Stream != nil {
+				resp.Stream.OnFinish(func(*Result, error) { cancel() })
+				return resp, nil
+			}
+			cancel()
+			return resp, err
+		}
+	}
+}
+
+// Redact returns middleware that passes the text of every message through redact
+// before it is sent, such as to remove personal data
+func Redact(redact func(text string) string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			config := *req.Config
+			config.Messages = make([]Message, len(req.Config.Messages))
+			for i, msg := range req.Config.Messages {
+				msg.Content = redact(msg.Content)
+				if len(msg.Parts) > 0 {
+					parts := make([]Part, len(msg.Parts))
+					for j, part := range msg.Parts {
+						if part.Type == PartTypeText {
+							part.Text = redact(part.Text)
+						}
+						parts[j] = part
+					}
+					msg.Parts = parts
+				}
+				config.Messages[i] = msg
+			}
+
+			redacted := *req
+			redacted.Config = &config
+			return next(ctx, &redacted)
+		}
+	}
+}
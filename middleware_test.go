@@ -0,0 +1,189 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (*Response, error) {
+				calls = append(calls, name+" before")
+				resp, err := next(ctx, req)
+				calls = append(calls, name+" after")
+				return resp, err
+			}
+		}
+	}
+
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockProvider{
+		GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+			calls = append(calls, "provider")
+			return "Hello!", nil
+		},
+	})
+	client.Use(trace("first"), trace("second"))
+
+	text, err := client.GetText(context.Background())
+	if err != nil || text != "Hello!" {
+		t.Fatalf("Expected Hello!, got %q, %v", text, err)
+	}
+
+	expected := "first before, second before, provider, second after, first after"
+	if got := strings.Join(calls, ", "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestMiddlewareChangesRequest(t *testing.T) {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockProvider{
+		GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+			return "openai", nil
+		},
+	})
+	client.RegisterProvider(ProviderAnthropic, &MockProvider{
+		GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+			return "anthropic " + config.Model, nil
+		},
+	})
+
+	// Middleware can route requests to another provider
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			req.Config.Provider = ProviderAnthropic
+			req.Config.Model = "claude"
+			return next(ctx, req)
+		}
+	})
+
+	text, err := client.GetText(context.Background())
+	if err != nil || text != "anthropic claude" {
+		t.Errorf("Expected the anthropic provider, got %q, %v", text, err)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockStreamer{
+		MockProvider: MockProvider{
+			GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+				return "", errors.New("boom")
+			},
+		},
+		StreamTextFunc: func(ctx context.Context, config *Config) (*Stream, error) {
+			return NewStream(&sliceReader{deltas: []Delta{
+				{Text: "Hello"},
+				{Usage: &Usage{InputTokens: 3, OutputTokens: 5}},
+			}}), nil
+		},
+	})
+
+	type observation struct {
+		kind   RequestKind
+		result *Result
+		err    error
+	}
+	var observed []observation
+	client.Use(Observe(func(ctx context.Context, req *Request, result *Result, err error, duration time.Duration) {
+		observed = append(observed, observation{req.Kind, result, err})
+	}))
+
+	if _, err := client.GetText(context.Background()); err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	stream, err := client.StreamText(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(observed) != 1 {
+		t.Fatalf("Expected the stream to be observed once it ends, got %d observations", len(observed))
+	}
+	for stream.Next() {
+	}
+
+	if len(observed) != 2 {
+		t.Fatalf("Expected 2 observations, got %d", len(observed))
+	}
+	if observed[0].kind != RequestText || observed[0].err == nil {
+		t.Errorf("Unexpected text observation: %+v", observed[0])
+	}
+	if observed[1].kind != RequestStream || observed[1].result.Text != "Hello" || observed[1].result.Usage.OutputTokens != 5 {
+		t.Errorf("Unexpected stream observation: %+v", observed[1])
+	}
+}
+
+func TestObserveObjectAttempts(t *testing.T) {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"), WithRepair(1))
+	client.RegisterProvider(ProviderOpenAI, &MockObjectGenerator{GenerateObjectFunc: objectResponses(`{"name":`, `{"name":"Ada","age":36}`)})
+
+	var kinds []RequestKind
+	client.Use(Observe(func(ctx context.Context, req *Request, result *Result, err error, duration time.Duration) {
+		kinds = append(kinds, req.Kind)
+	}))
+
+	var person struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	if err := client.GetObject(context.Background(), &person); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Each repair attempt passes through the middleware
+	if len(kinds) != 2 || kinds[0] != RequestObject || kinds[1] != RequestObject {
+		t.Errorf("Expected 2 object requests, got %v", kinds)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockProvider{
+		GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	})
+	client.Use(Timeout(10 * time.Millisecond))
+
+	if _, err := client.GetText(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	var sent []Message
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockProvider{
+		GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+			sent = config.Messages
+			return "ok", nil
+		},
+	})
+	client.Use(Redact(strings.NewReplacer("555-0100", "[phone]").Replace))
+
+	messages := []Message{
+		UserMessage("Call me on 555-0100"),
+		UserMessageParts(TextPart("or 555-0100"), ImageURLPart("https://example.com/555-0100.png")),
+	}
+	if _, err := client.GetText(context.Background(), WithMessages(messages...)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if sent[0].Content != "Call me on [phone]" || sent[1].Parts[0].Text != "or [phone]" {
+		t.Errorf("Expected text to be redacted, got %+v", sent)
+	}
+	if sent[1].Parts[1].URL != "https://example.com/555-0100.png" {
+		t.Errorf("Expected image parts to be unchanged, got %+v", sent[1].Parts[1])
+	}
+	if messages[0].Content != "Call me on 555-0100" || messages[1].Parts[0].Text != "or 555-0100" {
+		t.Errorf("Expected the caller's messages to be unchanged")
+	}
+}
@@ -0,0 +1,281 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCapabilityNotSupported is returned when a request needs a feature the model does not have
+	ErrCapabilityNotSupported = errors.New("model does not support the requested capability")
+
+	// ErrModelDeprecated is returned for requests to a model past its deprecation date,
+	// when the request sets WithRejectDeprecated
+	ErrModelDeprecated = errors.New("model is deprecated")
+)
+
+// Capability is a feature a model may support
+type Capability string
+
+const (
+	CapabilityVision           Capability = "vision"            // image inputs
+	CapabilityDocuments        Capability = "documents"         // PDF inputs
+	CapabilityTools            Capability = "tools"             // tool calling
+	CapabilityJSONMode         Capability = "json_mode"         // ObjectModeJSON
+	CapabilityStructuredOutput Capability = "structured_output" // ObjectModeSchema
+	CapabilityStreaming        Capability = "streaming"
+)
+
+// ModelInfo describes a model's capabilities and limits
+type ModelInfo struct {
+	Provider Provider
+	Name     string
+
+	Capabilities []Capability
+
+	// ContextWindow is the number of input and output tokens the model can handle
+	ContextWindow int
+
+	// MaxOutputTokens is the most tokens the model can generate in one response
+	MaxOutputTokens int
+
+	// Price is the model's list price; a CostTracker uses the prices in its Pricing
+	Price Price
+
+	// DeprecationDate is when the provider retires the model, if announced
+	DeprecationDate time.Time
+}
+
+// Supports reports whether the model has a capability
+func (m ModelInfo) Supports(capability Capability) bool {
+	for _, c := range m.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Deprecated reports whether the model is past its deprecation date at the given time
+func (m ModelInfo) Deprecated(now time.Time) bool {
+	return !m.DeprecationDate.IsZero() && !now.Before(m.DeprecationDate)
+}
+
+// lookupModel returns the entry for a model, matching the longest name
+// that the model equals or starts with followed by a dash
+func lookupModel[T any](models map[string]T, model string) (T, bool) {
+	if entry, ok := models[model]; ok {
+		return entry, true
+	}
+
+	var match string
+	for name := range models {
+		if len(name) > len(match) && strings.HasPrefix(model, name+"-") {
+			match = name
+		}
+	}
+
+	entry, ok := models[match]
+	return entry, ok && match != ""
+}
+
+// ModelRegistry holds the known models of each provider. It is safe for concurrent use.
+type ModelRegistry struct {
+	mu     sync.RWMutex
+	models map[Provider]map[string]ModelInfo
+}
+
+// NewModelRegistry creates a registry holding the given models
+func NewModelRegistry(models ...ModelInfo) *ModelRegistry {
+	r := &ModelRegistry{models: make(map[Provider]map[string]ModelInfo)}
+	for _, info := range models {
+		r.Register(info)
+	}
+	return r
+}
+
+// Register adds a model, replacing any existing model with the same provider and name. Its
+// details also apply to versions of the model, such as "gpt-4o-2024-08-06" for "gpt-4o",
+// that are not registered themselves.
+func (r *ModelRegistry) Register(info ModelInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.models[info.Provider] == nil {
+		r.models[info.Provider] = make(map[string]ModelInfo)
+	}
+	r.models[info.Provider][info.Name] = info
+}
+
+// Lookup returns the details of a model
+func (r *ModelRegistry) Lookup(provider Provider, model string) (ModelInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return lookupModel(r.models[provider], model)
+}
+
+// Models returns the registered models, sorted by provider and name
+func (r *ModelRegistry) Models() []ModelInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var models []ModelInfo
+	for _, byName := range r.models {
+		for _, info := range byName {
+			models = append(models, info)
+		}
+	}
+
+	sort.Slice(models, func(i, j int) bool {
+		if models[i].Provider != models[j].Provider {
+			return models[i].Provider < models[j].Provider
+		}
+		return models[i].Name < models[j].Name
+	})
+	return models
+}
+
+// DefaultModels is the registry clients use unless WithModelRegistry is set.
+// Register models with it to make them known to every client.
+var DefaultModels = NewModelRegistry(knownModels()...)
+
+// WithModelRegistry sets the registry the client checks requests against (defaults to DefaultModels).
+// An empty registry, such as NewModelRegistry(), turns the checks off.
+func WithModelRegistry(registry *ModelRegistry) Option {
+	return func(c *Config) {
+		c.Models = registry
+	}
+}
+
+// WithRejectDeprecated fails requests to models past their deprecation date with
+// ErrModelDeprecated. Without it, such requests are sent and a warning is logged.
+func WithRejectDeprecated() Option {
+	return func(c *Config) {
+		c.RejectDeprecated = true
+	}
+}
+
+// modelInfo returns the details of the requested model from the config's registry
+func modelInfo(config *Config) (ModelInfo, bool) {
+	registry := config.Models
+	if registry == nil {
+		registry = DefaultModels
+	}
+	return registry.Lookup(config.Provider, config.Model)
+}
+
+// checkModel reports an error if the requested model is known to lack a feature the request
+// needs. Requests to unknown models are not checked.
+func checkModel(ctx context.Context, req *Request) error {
+	config := req.Config
+	info, ok := modelInfo(config)
+	if !ok {
+		return nil
+	}
+
+	if config.RejectDeprecated && info.Deprecated(time.Now()) {
+		return fmt.Errorf("%w: %s was retired on %s", ErrModelDeprecated, config.Model, info.DeprecationDate.Format(time.DateOnly))
+	}
+	if !info.DeprecationDate.IsZero() && config.Logger != nil {
+		attrs := []interface{}{
+			"provider", string(config.Provider),
+			"model", config.Model,
+			"deprecation_date", info.DeprecationDate.Format(time.DateOnly),
+		}
+		if info.Deprecated(time.Now()) {
+			config.Logger.WarnContext(ctx, "model is deprecated", attrs...)
+		} else {
+			config.Logger.InfoContext(ctx, "model will be retired", attrs...)
+		}
+	}
+
+	var needs []Capability
+	if len(config.Tools) > 0 {
+		needs = append(needs, CapabilityTools)
+	}
+	for _, msg := range config.Messages {
+		for _, part := range msg.Parts {
+			switch part.Type {
+			case PartTypeImage:
+				needs = append(needs, CapabilityVision)
+			case PartTypeDocument:
+				needs = append(needs, CapabilityDocuments)
+			}
+		}
+	}
+	if req.Kind == RequestStream || req.Kind == RequestObjectStream {
+		needs = append(needs, CapabilityStreaming)
+	}
+	if req.Kind == RequestObject || req.Kind == RequestObjectStream {
+		switch config.ObjectMode {
+		case ObjectModeJSON:
+			needs = append(needs, CapabilityJSONMode)
+		case ObjectModeSchema:
+			needs = append(needs, CapabilityStructuredOutput)
+		case ObjectModeTool:
+			needs = append(needs, CapabilityTools)
+		}
+	}
+
+	for _, capability := range needs {
+		if !info.Supports(capability) {
+			return fmt.Errorf("%w: %s does not support %s", ErrCapabilityNotSupported, config.Model, capability)
+		}
+	}
+
+	if info.MaxOutputTokens > 0 && config.MaxTokens > info.MaxOutputTokens {
+		return fmt.Errorf("%w: max tokens %d exceeds the %d output tokens of %s", ErrInvalidRequest, config.MaxTokens, info.MaxOutputTokens, config.Model)
+	}
+
+	return nil
+}
+
+// knownModels returns the details of the built-in providers' models, as published by each
+// provider, with their list prices
+func knownModels() []ModelInfo {
+	var (
+		gpt      = []Capability{CapabilityVision, CapabilityDocuments, CapabilityTools, CapabilityJSONMode, CapabilityStructuredOutput, CapabilityStreaming}
+		gptText  = []Capability{CapabilityTools, CapabilityJSONMode, CapabilityStructuredOutput, CapabilityStreaming}
+		claude   = []Capability{CapabilityVision, CapabilityDocuments, CapabilityTools, CapabilityStructuredOutput, CapabilityStreaming}
+		claude3  = []Capability{CapabilityVision, CapabilityTools, CapabilityStructuredOutput, CapabilityStreaming}
+		retireOn = func(date string) time.Time {
+			t, _ := time.Parse(time.DateOnly, date)
+			return t
+		}
+	)
+
+	models := []ModelInfo{
+		{Provider: ProviderOpenAI, Name: "gpt-4o", Capabilities: gpt, ContextWindow: 128000, MaxOutputTokens: 16384},
+		{Provider: ProviderOpenAI, Name: "gpt-4o-mini", Capabilities: gpt, ContextWindow: 128000, MaxOutputTokens: 16384},
+		{Provider: ProviderOpenAI, Name: "gpt-4.1", Capabilities: gpt, ContextWindow: 1047576, MaxOutputTokens: 32768},
+		{Provider: ProviderOpenAI, Name: "gpt-4.1-mini", Capabilities: gpt, ContextWindow: 1047576, MaxOutputTokens: 32768},
+		{Provider: ProviderOpenAI, Name: "gpt-4.1-nano", Capabilities: gpt, ContextWindow: 1047576, MaxOutputTokens: 32768},
+		{Provider: ProviderOpenAI, Name: "gpt-4-turbo", Capabilities: []Capability{CapabilityVision, CapabilityTools, CapabilityJSONMode, CapabilityStreaming}, ContextWindow: 128000, MaxOutputTokens: 4096},
+		{Provider: ProviderOpenAI, Name: "gpt-4", Capabilities: []Capability{CapabilityTools, CapabilityStreaming}, ContextWindow: 8192, MaxOutputTokens: 8192},
+		{Provider: ProviderOpenAI, Name: "gpt-3.5-turbo", Capabilities: []Capability{CapabilityTools, CapabilityJSONMode, CapabilityStreaming}, ContextWindow: 16385, MaxOutputTokens: 4096},
+		{Provider: ProviderOpenAI, Name: "o1", Capabilities: gpt, ContextWindow: 200000, MaxOutputTokens: 100000},
+		{Provider: ProviderOpenAI, Name: "o1-mini", Capabilities: []Capability{CapabilityStreaming}, ContextWindow: 128000, MaxOutputTokens: 65536},
+		{Provider: ProviderOpenAI, Name: "o3", Capabilities: gpt, ContextWindow: 200000, MaxOutputTokens: 100000},
+		{Provider: ProviderOpenAI, Name: "o3-mini", Capabilities: gptText, ContextWindow: 200000, MaxOutputTokens: 100000},
+		{Provider: ProviderOpenAI, Name: "o4-mini", Capabilities: gpt, ContextWindow: 200000, MaxOutputTokens: 100000},
+
+		{Provider: ProviderAnthropic, Name: "claude-opus-4", Capabilities: claude, ContextWindow: 200000, MaxOutputTokens: 32000},
+		{Provider: ProviderAnthropic, Name: "claude-sonnet-4", Capabilities: claude, ContextWindow: 200000, MaxOutputTokens: 64000},
+		{Provider: ProviderAnthropic, Name: "claude-3-7-sonnet", Capabilities: claude, ContextWindow: 200000, MaxOutputTokens: 64000},
+		{Provider: ProviderAnthropic, Name: "claude-3-5-sonnet", Capabilities: claude, ContextWindow: 200000, MaxOutputTokens: 8192, DeprecationDate: retireOn("2025-10-22")},
+		{Provider: ProviderAnthropic, Name: "claude-3-5-haiku", Capabilities: claude, ContextWindow: 200000, MaxOutputTokens: 8192},
+		{Provider: ProviderAnthropic, Name: "claude-3-opus", Capabilities: claude3, ContextWindow: 200000, MaxOutputTokens: 4096, DeprecationDate: retireOn("2026-01-05")},
+		{Provider: ProviderAnthropic, Name: "claude-3-haiku", Capabilities: claude3, ContextWindow: 200000, MaxOutputTokens: 4096},
+	}
+
+	for i, info := range models {
+		models[i].Price = defaultPrices[info.Provider][info.Name]
+	}
+	return models
+}
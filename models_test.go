@@ -0,0 +1,221 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModelRegistryLookup(t *testing.T) {
+	tests := []struct {
+		provider Provider
+		model    string
+		expected string
+		found    bool
+	}{
+		{ProviderOpenAI, "gpt-4o", "gpt-4o", true},
+		{ProviderOpenAI, "gpt-4o-2024-08-06", "gpt-4o", true},
+		{ProviderOpenAI, "gpt-4o-mini-2024-07-18", "gpt-4o-mini", true},
+		{ProviderOpenAI, "o1-mini-2024-09-12", "o1-mini", true},
+		{ProviderOpenAI, "o1-2024-12-17", "o1", true},
+		{ProviderAnthropic, "claude-sonnet-4-20250514", "claude-sonnet-4", true},
+		{ProviderAnthropic, "gpt-4o", "", false},
+		{ProviderOpenAI, "gpt-4oo", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.provider)+"/"+tt.model, func(t *testing.T) {
+			info, ok := DefaultModels.Lookup(tt.provider, tt.model)
+			if ok != tt.found {
+				t.Fatalf("Expected found to be %v, got %v", tt.found, ok)
+			}
+			if info.Name != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, info.Name)
+			}
+		})
+	}
+
+	info, _ := DefaultModels.Lookup(ProviderOpenAI, "gpt-4o")
+	if info.Price != defaultPrices[ProviderOpenAI]["gpt-4o"] {
+		t.Errorf("Expected the list price, got %+v", info.Price)
+	}
+}
+
+func TestModelRegistryRegister(t *testing.T) {
+	registry := NewModelRegistry(ModelInfo{Provider: ProviderOpenAI, Name: "b-model"})
+	registry.Register(ModelInfo{Provider: ProviderAnthropic, Name: "a-model"})
+	registry.Register(ModelInfo{Provider: ProviderOpenAI, Name: "b-model", ContextWindow: 10})
+
+	models := registry.Models()
+	if len(models) != 2 || models[0].Name != "a-model" || models[1].ContextWindow != 10 {
+		t.Errorf("Expected the registered models sorted by provider, got %+v", models)
+	}
+}
+
+func TestClientChecksModel(t *testing.T) {
+	registry := NewModelRegistry(
+		ModelInfo{Provider: ProviderOpenAI, Name: "text-model", MaxOutputTokens: 1000},
+		ModelInfo{Provider: ProviderOpenAI, Name: "full-model", Capabilities: []Capability{
+			CapabilityVision, CapabilityDocuments, CapabilityTools, CapabilityJSONMode, CapabilityStructuredOutput,
+		}},
+		ModelInfo{Provider: ProviderOpenAI, Name: "retiring-model", DeprecationDate: time.Now().Add(24 * time.Hour)},
+		ModelInfo{Provider: ProviderOpenAI, Name: "retired-model", DeprecationDate: time.Now().Add(-24 * time.Hour)},
+	)
+
+	image := UserMessageParts(ImageURLPart("https://example.com/cat.png"))
+	document := UserMessageParts(DocumentURLPart("https://example.com/report.pdf"))
+
+	tests := []struct {
+		name     string
+		model    string
+		options  []Option
+		object   bool
+		expected error
+	}{
+		{"plain text", "text-model", nil, false, nil},
+		{"tools", "text-model", []Option{WithTools(Tool{Name: "lookup"})}, false, ErrCapabilityNotSupported},
+		{"images", "text-model", []Option{WithMessages(image)}, false, ErrCapabilityNotSupported},
+		{"documents", "text-model", []Option{WithMessages(document)}, false, ErrCapabilityNotSupported},
+		{"json mode", "text-model", []Option{WithObjectMode(ObjectModeJSON)}, true, ErrCapabilityNotSupported},
+		{"prompt mode", "text-model", []Option{WithObjectMode(ObjectModePrompt)}, true, nil},
+		{"supported features", "full-model", []Option{WithTools(Tool{Name: "lookup"}), WithMessages(image, document)}, false, nil},
+		{"schema mode", "full-model", []Option{WithObjectMode(ObjectModeSchema)}, true, nil},
+		{"max tokens", "text-model", []Option{WithMaxTokens(1001)}, false, ErrInvalidRequest},
+		{"versioned model", "text-model-2024", []Option{WithMessages(image)}, false, ErrCapabilityNotSupported},
+		{"unknown model", "other-model", []Option{WithMessages(image), WithMaxTokens(100000)}, false, nil},
+		{"deprecation date ahead", "retiring-model", nil, false, nil},
+		{"deprecation date passed", "retired-model", nil, false, nil},
+		{"deprecated models rejected", "retired-model", []Option{WithRejectDeprecated()}, false, ErrModelDeprecated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			client := NewClient(WithProvider(ProviderOpenAI), WithModel(tt.model), WithModelRegistry(registry))
+			client.RegisterProvider(ProviderOpenAI, &MockGenerator{
+				MockProvider: MockProvider{
+					GetObjectFunc: func(ctx context.Context, config *Config, target interface{}) error {
+						called = true
+						return nil
+					},
+				},
+				GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+					called = true
+					return &Result{Text: "ok"}, nil
+				},
+			})
+
+			var err error
+			if tt.object {
+				var target struct{}
+				err = client.GetObject(context.Background(), &target, tt.options...)
+			} else {
+				_, err = client.GetText(context.Background(), tt.options...)
+			}
+
+			if tt.expected == nil {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if !called {
+					t.Errorf("Expected the provider to be called")
+				}
+				return
+			}
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+			if called {
+				t.Errorf("Expected the provider not to be called")
+			}
+		})
+	}
+}
+
+func TestClientLogsDeprecation(t *testing.T) {
+	registry := NewModelRegistry(
+		ModelInfo{Provider: ProviderOpenAI, Name: "retiring-model", DeprecationDate: time.Now().Add(24 * time.Hour)},
+		ModelInfo{Provider: ProviderOpenAI, Name: "retired-model", DeprecationDate: time.Now().Add(-24 * time.Hour)},
+	)
+
+	tests := []struct {
+		model    string
+		expected string
+	}{
+		{"retiring-model", `level=INFO msg="model will be retired"`},
+		{"retired-model", `level=WARN msg="model is deprecated"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+			client := NewClient(WithProvider(ProviderOpenAI), WithModel(tt.model), WithModelRegistry(registry), WithLogger(logger))
+			client.RegisterProvider(ProviderOpenAI, &MockGenerator{
+				GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+					return &Result{Text: "ok"}, nil
+				},
+			})
+
+			if _, err := client.GetText(context.Background()); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			lines := buf.String()
+			if !strings.Contains(lines, tt.expected) {
+				t.Errorf("Expected log to contain %s, got %s", tt.expected, lines)
+			}
+			if tt.model == "retiring-model" && strings.Contains(lines, "level=WARN") {
+				t.Errorf("Expected no warning before the deprecation date, got %s", lines)
+			}
+		})
+	}
+}
+
+func TestClientChecksDefaultModels(t *testing.T) {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("gpt-4"))
+	client.RegisterProvider(ProviderOpenAI, &MockProvider{
+		GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+			return "ok", nil
+		},
+	})
+
+	image := UserMessageParts(ImageURLPart("https://example.com/cat.png"))
+	if _, err := client.GetText(context.Background(), WithMessages(image)); !errors.Is(err, ErrCapabilityNotSupported) {
+		t.Errorf("Expected ErrCapabilityNotSupported, got %v", err)
+	}
+
+	// An empty registry turns the checks off
+	if _, err := client.GetText(context.Background(), WithMessages(image), WithModelRegistry(NewModelRegistry())); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestContextWindowFromModelRegistry(t *testing.T) {
+	var sent []Message
+	client := windowClient(&sent)
+	client.Use(ContextWindow(0, WithContextTokenCounter(countChars)))
+
+	messages := WithMessages(UserMessage("u1"), UserMessage("u2"), UserMessage("u3"))
+	registry := NewModelRegistry(ModelInfo{Provider: ProviderOpenAI, Name: "test-model", ContextWindow: 4})
+
+	if _, err := client.GetText(context.Background(), messages, WithModelRegistry(registry)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := contents(sent); got != "u2,u3" {
+		t.Errorf("Expected the model's context window to apply, got %s", got)
+	}
+
+	// Unknown models are sent unchanged
+	if _, err := client.GetText(context.Background(), messages); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := contents(sent); got != "u1,u2,u3" {
+		t.Errorf("Expected all messages, got %s", got)
+	}
+}
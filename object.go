@@ -0,0 +1,235 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ObjectMode selects how a provider obtains structured output for GetObject
+type ObjectMode string
+
+const (
+	// ObjectModeAuto lets the provider pick the most reliable mode the model supports
+	ObjectModeAuto ObjectMode = ""
+
+	// ObjectModePrompt describes the schema in the prompt and parses the text response
+	ObjectModePrompt ObjectMode = "prompt"
+
+	// ObjectModeJSON uses the provider's JSON mode, which guarantees valid JSON but not the schema
+	ObjectModeJSON ObjectMode = "json"
+
+	// ObjectModeSchema uses the provider's native schema-constrained output
+	ObjectModeSchema ObjectMode = "schema"
+
+	// ObjectModeTool forces the model to call a single tool whose input schema matches the target
+	ObjectModeTool ObjectMode = "tool"
+)
+
+// ErrObjectModeNotSupported is returned when a provider cannot use the requested object mode
+var ErrObjectModeNotSupported = errors.New("object mode not supported")
+
+// WithObjectMode sets how the provider obtains structured output for GetObject
+func WithObjectMode(mode ObjectMode) Option {
+	return func(c *Config) {
+		c.ObjectMode = mode
+	}
+}
+
+// ObjectInstructions returns a system prompt instructing the model to respond
+// with JSON matching the schema of target
+func ObjectInstructions(target interface{}) (string, error) {
+	schema, err := SchemaFor(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate schema: %w", err)
+	}
+
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	return fmt.Sprintf("You are a helpful assistant that responds with JSON matching this JSON Schema:\n\n%s\n\nYour response should be valid JSON and nothing else.", schemaJSON), nil
+}
+
+// ErrInvalidObjectTarget is returned when a structured response cannot be decoded into the given target type
+var ErrInvalidObjectTarget = errors.New("invalid object target")
+
+// ErrInvalidObject is matched by errors returned when a structured response cannot be decoded or fails validation
+var ErrInvalidObject = errors.New("invalid object response")
+
+// Validator is implemented by GetObject targets that check their own contents after decoding
+type Validator interface {
+	Validate() error
+}
+
+// InvalidObjectError is returned when a model's structured response cannot be decoded or fails validation
+type InvalidObjectError struct {
+	// Raw is the response text the object was decoded from
+	Raw string
+
+	// Err describes what was wrong with the response
+	Err error
+}
+
+func (e *InvalidObjectError) Error() string {
+	return fmt.Sprintf("%v: %v: %s", ErrInvalidObject, e.Err, e.Raw)
+}
+
+func (e *InvalidObjectError) Unwrap() []error {
+	return []error{ErrInvalidObject, e.Err}
+}
+
+// ObjectAttempt records a single invalid response during GetObject repair
+type ObjectAttempt struct {
+	Raw string
+	Err error
+}
+
+// ObjectRepairError is returned when GetObject still has no valid object after all repair attempts
+type ObjectRepairError struct {
+	Attempts []ObjectAttempt
+}
+
+func (e *ObjectRepairError) Error() string {
+	last := e.Attempts[len(e.Attempts)-1]
+	return fmt.Sprintf("%v after %d attempts: %v", ErrInvalidObject, len(e.Attempts), last.Err)
+}
+
+func (e *ObjectRepairError) Unwrap() []error {
+	return []error{ErrInvalidObject, e.Attempts[len(e.Attempts)-1].Err}
+}
+
+// RepairStrategy returns the messages to send when retrying after an invalid structured response
+type RepairStrategy func(messages []Message, raw string, err error) []Message
+
+// DefaultRepairStrategy shows the model its previous response and the error, and asks it to try again
+func DefaultRepairStrategy(messages []Message, raw string, err error) []Message {
+	repaired := make([]Message, 0, len(messages)+2)
+	repaired = append(repaired, messages...)
+	return append(repaired,
+		AssistantMessage(raw),
+		UserMessage(fmt.Sprintf("Your previous response was invalid: %v\n\nRespond again with corrected JSON only.", err)),
+	)
+}
+
+// WithRepair makes GetObject re-prompt the model up to attempts times when its response is invalid
+func WithRepair(attempts int) Option {
+	return func(c *Config) {
+		c.RepairAttempts = attempts
+	}
+}
+
+// WithRepairStrategy sets how GetObject builds the messages for a repair attempt
+func WithRepairStrategy(strategy RepairStrategy) Option {
+	return func(c *Config) {
+		c.RepairStrategy = strategy
+	}
+}
+
+// DecodeObject decodes a model's JSON response into target, removing any markdown
+// code fence, then validates it against the target's schema and its Validate method.
+// Failures are returned as *InvalidObjectError.
+func DecodeObject(text string, target interface{}) error {
+	jsonStr := strings.TrimSpace(text)
+
+	// If response starts with ``` (markdown code block), clean it up
+	if strings.HasPrefix(jsonStr, "```") {
+		jsonStr = strings.TrimPrefix(jsonStr, "```")
+		jsonStr = strings.TrimPrefix(jsonStr, "json")
+		if idx := strings.LastIndex(jsonStr, "```"); idx != -1 {
+			jsonStr = jsonStr[:idx]
+		}
+	}
+
+	jsonStr = strings.TrimSpace(jsonStr)
+
+	schema, err := SchemaFor(target)
+	if err != nil {
+		return fmt.Errorf("failed to generate schema: %w", err)
+	}
+
+	// Validate against the schema first, as its errors point at the offending field
+	decoder := json.NewDecoder(strings.NewReader(jsonStr))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return &InvalidObjectError{Raw: jsonStr, Err: err}
+	}
+
+	if err := schema.Validate(value); err != nil {
+		return &InvalidObjectError{Raw: jsonStr, Err: err}
+	}
+
+	if err := json.Unmarshal([]byte(jsonStr), target); err != nil {
+		return &InvalidObjectError{Raw: jsonStr, Err: err}
+	}
+
+	if validator, ok := target.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return &InvalidObjectError{Raw: jsonStr, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// ObjectResult is a structured response decoded into a value of type T
+type ObjectResult[T any] struct {
+	// Object is the decoded response
+	Object T
+
+	// Result holds the JSON the object was decoded from and the response metadata.
+	// Usage includes any repair attempts.
+	Result
+}
+
+// Object gets a structured response from the client decoded into a value of type T
+//
+//	recipe, err := ai.Object[Recipe](ctx, client, ai.WithMessages(...))
+func Object[T any](ctx context.Context, client *Client, options ...Option) (T, error) {
+	result, err := GenerateObject[T](ctx, client, options...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return result.Object, nil
+}
+
+// GenerateObject gets a structured response from the client decoded into a value
+// of type T, along with the response metadata
+func GenerateObject[T any](ctx context.Context, client *Client, options ...Option) (*ObjectResult[T], error) {
+	if err := checkObjectType(reflect.TypeOf((*T)(nil)).Elem()); err != nil {
+		return nil, err
+	}
+
+	var object T
+	result, err := client.generateObject(ctx, &object, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectResult[T]{Object: object, Result: *result}, nil
+}
+
+// checkObjectType reports an error if values of type t cannot be decoded from JSON
+func checkObjectType(t reflect.Type) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// Only the empty interface can hold a decoded value
+	if t.Kind() == reflect.Interface && t.NumMethod() > 0 {
+		return fmt.Errorf("%w: cannot decode into interface type %s", ErrInvalidObjectTarget, t)
+	}
+
+	if _, err := SchemaForType(t); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidObjectTarget, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,255 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/gnfisher/go-ai-sdk/internal/partialjson"
+)
+
+// ObjectStreamer is implemented by providers that can stream structured responses.
+// The stream's text is the JSON encoding of the target as it is generated.
+type ObjectStreamer interface {
+	StreamObject(ctx context.Context, config *Config, target interface{}) (*Stream, error)
+}
+
+// ObjectStream is a streamed structured response. Call Next to advance through
+// progressively filled snapshots of the object, then Err to check for failures
+// and Result for the decoded and validated object.
+//
+//	stream, err := ai.StreamObject[Article](ctx, client, ai.WithMessages(...))
+//	if err != nil { ... }
+//	defer stream.Close()
+//	for stream.Next() {
+//		render(stream.Partial())
+//	}
+//	if err := stream.Err(); err != nil { ... }
+type ObjectStream[T any] struct {
+	stream   *Stream
+	text     []byte
+	snapshot []byte
+	partial  T
+	result   *ObjectResult[T]
+	err      error
+}
+
+// StreamObject streams a structured response from the client decoded into snapshots of type T
+func StreamObject[T any](ctx context.Context, client *Client, options ...Option) (*ObjectStream[T], error) {
+	stream, err := streamObject[T](ctx, client, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectStream[T]{stream: stream}, nil
+}
+
+// Next advances to the next snapshot, returning false when the stream ends or fails.
+// Snapshots are only produced when more of the object has been parsed.
+func (s *ObjectStream[T]) Next() bool {
+	for s.stream.Next() {
+		s.text = append(s.text, s.stream.Delta().Text...)
+
+		value, ok := partialjson.Parse(trimFence(s.text))
+		if !ok {
+			continue
+		}
+
+		snapshot, err := json.Marshal(value)
+		if err != nil || bytes.Equal(snapshot, s.snapshot) {
+			continue
+		}
+		s.snapshot = snapshot
+
+		// Partial values may not fit T yet, so keep whatever decodes
+		var partial T
+		var typeErr *json.UnmarshalTypeError
+		if err := json.Unmarshal(snapshot, &partial); err != nil && !errors.As(err, &typeErr) {
+			continue
+		}
+
+		s.partial = partial
+		return true
+	}
+
+	if s.result == nil && s.err == nil {
+		s.result, s.err = finishObject[T](s.stream)
+		if s.result != nil {
+			s.partial = s.result.Object
+		}
+	}
+	return false
+}
+
+// Partial returns the current snapshot of the object
+func (s *ObjectStream[T]) Partial() T {
+	return s.partial
+}
+
+// Err returns the error that ended the stream, if any. A response that is
+// invalid once complete is reported as an *InvalidObjectError.
+func (s *ObjectStream[T]) Err() error {
+	return s.err
+}
+
+// Result returns the decoded and validated object along with the response metadata.
+// It is nil until Next has returned false, and when Err is not nil.
+func (s *ObjectStream[T]) Result() *ObjectResult[T] {
+	return s.result
+}
+
+// Close stops the stream and releases the underlying connection
+func (s *ObjectStream[T]) Close() error {
+	return s.stream.Close()
+}
+
+// ElementStream is a streamed structured response for a top-level array that yields
+// each element once it has been received completely.
+//
+//	stream, err := ai.StreamElements[Entity](ctx, client, ai.WithMessages(...))
+//	if err != nil { ... }
+//	defer stream.Close()
+//	for stream.Next() {
+//		fmt.Println(stream.Element())
+//	}
+//	if err := stream.Err(); err != nil { ... }
+type ElementStream[E any] struct {
+	stream   *Stream
+	text     []byte
+	elements []json.RawMessage
+	count    int
+	element  E
+	result   *ObjectResult[[]E]
+	err      error
+}
+
+// StreamElements streams a structured response of type []E from the client, element by element
+func StreamElements[E any](ctx context.Context, client *Client, options ...Option) (*ElementStream[E], error) {
+	stream, err := streamObject[[]E](ctx, client, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &ElementStream[E]{stream: stream}, nil
+}
+
+// Next advances to the next complete element, returning false when the stream ends or fails.
+// Each element is validated before it is returned.
+func (s *ElementStream[E]) Next() bool {
+	if s.err != nil {
+		return false
+	}
+
+	for s.count == len(s.elements) {
+		if !s.stream.Next() {
+			if s.result == nil {
+				s.result, s.err = finishObject[[]E](s.stream)
+			}
+			return false
+		}
+
+		s.text = append(s.text, s.stream.Delta().Text...)
+
+		text := trimFence(s.text)
+		elements, ok := partialjson.Elements(text)
+		if !ok && len(bytes.TrimSpace(text)) > 0 {
+			s.err = &InvalidObjectError{Raw: string(s.text), Err: errors.New("expected a JSON array")}
+			s.stream.Close()
+			return false
+		}
+		s.elements = elements
+	}
+
+	var element E
+	if err := DecodeObject(string(s.elements[s.count]), &element); err != nil {
+		s.err = fmt.Errorf("element %d: %w", s.count, err)
+		s.stream.Close()
+		return false
+	}
+
+	s.count++
+	s.element = element
+	return true
+}
+
+// Element returns the current element
+func (s *ElementStream[E]) Element() E {
+	return s.element
+}
+
+// Err returns the error that ended the stream, if any. Invalid elements and
+// responses are reported as an *InvalidObjectError.
+func (s *ElementStream[E]) Err() error {
+	return s.err
+}
+
+// Result returns the decoded and validated array along with the response metadata.
+// It is nil until Next has returned false, and when Err is not nil.
+func (s *ElementStream[E]) Result() *ObjectResult[[]E] {
+	return s.result
+}
+
+// Close stops the stream and releases the underlying connection
+func (s *ElementStream[E]) Close() error {
+	return s.stream.Close()
+}
+
+// streamObject starts streaming a structured response of type T from the client's provider
+func streamObject[T any](ctx context.Context, client *Client, options ...Option) (*Stream, error) {
+	if err := checkObjectType(reflect.TypeOf((*T)(nil)).Elem()); err != nil {
+		return nil, err
+	}
+
+	config := client.mergeConfig(options...)
+
+	if _, err := client.provider(config); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.handle(ctx, &Request{Kind: RequestObjectStream, Config: config, Target: new(T)})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Stream, nil
+}
+
+// finishObject decodes and validates the complete response of a finished stream
+func finishObject[T any](stream *Stream) (*ObjectResult[T], error) {
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+
+	result := stream.Result()
+
+	var object T
+	if err := DecodeObject(result.Text, &object); err != nil {
+		return nil, err
+	}
+
+	return &ObjectResult[T]{Object: object, Result: *result}, nil
+}
+
+// trimFence removes a markdown code fence around a streamed JSON response
+func trimFence(text []byte) []byte {
+	text = bytes.TrimLeft(text, " \t\r\n")
+	if len(text) < 3 && bytes.HasPrefix([]byte("```"), text) {
+		return nil
+	}
+	if !bytes.HasPrefix(text, []byte("```")) {
+		return text
+	}
+
+	// Skip the opening fence and its language tag
+	newline := bytes.IndexByte(text, '\n')
+	if newline == -1 {
+		return nil
+	}
+	text = text[newline+1:]
+
+	if end := bytes.Index(text, []byte("```")); end != -1 {
+		text = text[:end]
+	}
+	return text
+}
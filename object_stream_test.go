@@ -0,0 +1,176 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// MockObjectStreamer implements LLMProvider and ObjectStreamer for testing
+type MockObjectStreamer struct {
+	MockProvider
+	StreamObjectFunc func(ctx context.Context, config *Config, target interface{}) (*Stream, error)
+}
+
+func (m *MockObjectStreamer) StreamObject(ctx context.Context, config *Config, target interface{}) (*Stream, error) {
+	return m.StreamObjectFunc(ctx, config, target)
+}
+
+// objectStreamClient returns a client whose provider streams the given text fragments
+func objectStreamClient(fragments ...string) (*Client, *sliceReader) {
+	reader := &sliceReader{}
+	reader.deltas = append(reader.deltas, Delta{ID: "resp_1", Model: "test-model"})
+	for _, fragment := range fragments {
+		reader.deltas = append(reader.deltas, Delta{Text: fragment})
+	}
+	reader.deltas = append(reader.deltas, Delta{FinishReason: FinishReasonStop, Usage: &Usage{InputTokens: 12, OutputTokens: 30}})
+
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockObjectStreamer{
+		StreamObjectFunc: func(ctx context.Context, config *Config, target interface{}) (*Stream, error) {
+			return NewStream(reader), nil
+		},
+	})
+
+	return client, reader
+}
+
+type streamedArticle struct {
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+	Words int      `json:"words"`
+}
+
+func TestStreamObject(t *testing.T) {
+	client, _ := objectStreamClient(`{"ti`, `tle":"Go`, `phers","tags":["go",`, `"ai"],"wor`, `ds":12`, `00}`)
+
+	stream, err := StreamObject[streamedArticle](context.Background(), client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	var snapshots []streamedArticle
+	for stream.Next() {
+		snapshots = append(snapshots, stream.Partial())
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The key fragment and the incomplete number do not produce snapshots
+	expected := []streamedArticle{
+		{},
+		{Title: "Go"},
+		{Title: "Gophers", Tags: []string{"go"}},
+		{Title: "Gophers", Tags: []string{"go", "ai"}},
+		{Title: "Gophers", Tags: []string{"go", "ai"}, Words: 1200},
+	}
+	if len(snapshots) != len(expected) {
+		t.Fatalf("Expected %d snapshots, got %d: %+v", len(expected), len(snapshots), snapshots)
+	}
+	for i, snapshot := range snapshots {
+		if snapshot.Title != expected[i].Title || len(snapshot.Tags) != len(expected[i].Tags) || snapshot.Words != expected[i].Words {
+			t.Errorf("Snapshot %d: expected %+v, got %+v", i, expected[i], snapshot)
+		}
+	}
+
+	result := stream.Result()
+	if result == nil {
+		t.Fatalf("Expected a result")
+	}
+	if result.Object.Words != 1200 || result.Model != "test-model" || result.Usage.OutputTokens != 30 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestStreamObjectInvalid(t *testing.T) {
+	client, _ := objectStreamClient("```json\n", `{"title":"Go","tags":[]`, "}\n```")
+
+	stream, err := StreamObject[streamedArticle](context.Background(), client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+	}
+
+	// The fence is removed but the words property is missing
+	if !errors.Is(stream.Err(), ErrInvalidObject) {
+		t.Errorf("Expected ErrInvalidObject, got %v", stream.Err())
+	}
+	if stream.Result() != nil {
+		t.Errorf("Expected no result for an invalid object")
+	}
+}
+
+func TestStreamElements(t *testing.T) {
+	client, _ := objectStreamClient(`[{"title":"A","tags":[],"words":1},`, `{"title":"B","ta`, `gs":[],"words":2},{"title":"C",`, `"tags":[],"words":3}]`)
+
+	stream, err := StreamElements[streamedArticle](context.Background(), client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	var titles []string
+	for stream.Next() {
+		titles = append(titles, stream.Element().Title)
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(titles) != 3 || titles[0] != "A" || titles[1] != "B" || titles[2] != "C" {
+		t.Errorf("Expected each element once, got %v", titles)
+	}
+	if result := stream.Result(); result == nil || len(result.Object) != 3 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestStreamElementsInvalid(t *testing.T) {
+	// Elements are validated as they arrive
+	client, reader := objectStreamClient(`[{"title":"A","tags":[],"words":1},`, `{"title":"B"},`, `{"title":"C","tags":[],"words":3}]`)
+
+	stream, err := StreamElements[streamedArticle](context.Background(), client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	count := 0
+	for stream.Next() {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 valid element, got %d", count)
+	}
+	if !errors.Is(stream.Err(), ErrInvalidObject) {
+		t.Errorf("Expected ErrInvalidObject, got %v", stream.Err())
+	}
+	if !reader.closed {
+		t.Errorf("Expected stream to be closed")
+	}
+
+	// Responses that are not arrays are rejected
+	client, _ = objectStreamClient(`{"title":`)
+
+	stream, err = StreamElements[streamedArticle](context.Background(), client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stream.Next() || !errors.Is(stream.Err(), ErrInvalidObject) {
+		t.Errorf("Expected ErrInvalidObject, got %v", stream.Err())
+	}
+}
+
+func TestStreamObjectNotSupported(t *testing.T) {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockProvider{})
+
+	_, err := StreamObject[streamedArticle](context.Background(), client)
+	if !errors.Is(err, ErrStreamingNotSupported) {
+		t.Errorf("Expected ErrStreamingNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestObjectInstructions(t *testing.T) {
+	instructions, err := ObjectInstructions(&schemaAddress{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.Contains(instructions, `"properties":{"city":{"type":"string"},"street":{"type":"string"}}`) {
+		t.Errorf("Expected instructions to contain the schema, got %s", instructions)
+	}
+}
+
+type validatedRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+func (r *validatedRange) Validate() error {
+	if r.Min > r.Max {
+		return errors.New("min must not exceed max")
+	}
+	return nil
+}
+
+func TestDecodeObject(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		expectError string
+	}{
+		{"plain", `{"min":1,"max":2}`, ""},
+		{"fenced", "```json\n{\"min\":1,\"max\":2}\n```", ""},
+		{"fenced without language", "```\n{\"min\":1,\"max\":2}\n```", ""},
+		{"invalid JSON", `{"min":1,`, "unexpected EOF"},
+		{"schema violation", `{"min":1}`, `$: missing required property "max"`},
+		{"validator", `{"min":3,"max":2}`, "min must not exceed max"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var target validatedRange
+			err := DecodeObject(tt.text, &target)
+
+			if tt.expectError == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if target.Min != 1 || target.Max != 2 {
+					t.Errorf("Unexpected target: %+v", target)
+				}
+				return
+			}
+
+			var invalid *InvalidObjectError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("Expected InvalidObjectError, got %v", err)
+			}
+			if !errors.Is(err, ErrInvalidObject) {
+				t.Errorf("Expected error to match ErrInvalidObject")
+			}
+			if invalid.Err.Error() != tt.expectError {
+				t.Errorf("Expected error %q, got %q", tt.expectError, invalid.Err)
+			}
+			if invalid.Raw == "" {
+				t.Errorf("Expected raw response to be recorded")
+			}
+		})
+	}
+}
+
+// MockObjectGenerator implements LLMProvider and ObjectGenerator for testing
+type MockObjectGenerator struct {
+	MockProvider
+	GenerateObjectFunc func(ctx context.Context, config *Config, target interface{}) (*Result, error)
+}
+
+func (m *MockObjectGenerator) GenerateObject(ctx context.Context, config *Config, target interface{}) (*Result, error) {
+	return m.GenerateObjectFunc(ctx, config, target)
+}
+
+// objectResponses returns a GenerateObject func that replies with the given responses in order
+func objectResponses(responses ...string) func(ctx context.Context, config *Config, target interface{}) (*Result, error) {
+	calls := 0
+	return func(ctx context.Context, config *Config, target interface{}) (*Result, error) {
+		result := &Result{
+			Text:  responses[calls],
+			Usage: Usage{InputTokens: 10, OutputTokens: 5, CachedInputTokens: 4},
+			Model: "test-model-001",
+		}
+		calls++
+		return result, DecodeObject(result.Text, target)
+	}
+}
+
+func TestObject(t *testing.T) {
+	type City struct {
+		Name       string `json:"name"`
+		Population int    `json:"population"`
+	}
+
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockObjectGenerator{
+		GenerateObjectFunc: objectResponses(`{"name":"Paris","population":2100000}`),
+	})
+
+	city, err := Object[City](context.Background(), client, WithMessages(UserMessage("Name a city.")))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if city.Name != "Paris" || city.Population != 2100000 {
+		t.Errorf("Unexpected city: %+v", city)
+	}
+
+	// Non-struct types work too
+	client.RegisterProvider(ProviderOpenAI, &MockObjectGenerator{
+		GenerateObjectFunc: objectResponses(`["red","green"]`),
+	})
+
+	colors, err := Object[[]string](context.Background(), client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(colors) != 2 || colors[1] != "green" {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+
+	// ...including pointers
+	client.RegisterProvider(ProviderOpenAI, &MockObjectGenerator{
+		GenerateObjectFunc: objectResponses(`{"name":"Rome","population":2800000}`),
+	})
+
+	cityPtr, err := Object[*City](context.Background(), client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cityPtr == nil || cityPtr.Name != "Rome" {
+		t.Errorf("Unexpected city: %+v", cityPtr)
+	}
+}
+
+func TestGenerateObjectResult(t *testing.T) {
+	type Answer struct {
+		Value int `json:"value"`
+	}
+
+	pricing := NewPricing()
+	pricing.Set(ProviderOpenAI, "test-model", Price{Input: 1, Output: 2})
+
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockObjectGenerator{
+		GenerateObjectFunc: objectResponses(`{"value":"x"}`, `{"value":42}`),
+	})
+	client.Use(NewCostTracker(WithPricing(pricing)).Middleware())
+
+	result, err := GenerateObject[Answer](context.Background(), client, WithRepair(1))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Object.Value != 42 {
+		t.Errorf("Expected 42, got %d", result.Object.Value)
+	}
+	if result.Text != `{"value":42}` || result.Model != "test-model-001" {
+		t.Errorf("Unexpected metadata: %+v", result.Result)
+	}
+
+	// Usage and cost include the repair attempt
+	if result.Usage.InputTokens != 20 || result.Usage.OutputTokens != 10 || result.Usage.CachedInputTokens != 8 {
+		t.Errorf("Expected usage summed over attempts, got %+v", result.Usage)
+	}
+	if !approxEqual(result.Cost, 40e-6) {
+		t.Errorf("Expected cost summed over attempts, got %v", result.Cost)
+	}
+
+	// Providers without ObjectGenerator still work, without metadata
+	client.RegisterProvider(ProviderAnthropic, &MockProvider{
+		GetObjectFunc: func(ctx context.Context, config *Config, target interface{}) error {
+			return DecodeObject(`{"value":7}`, target)
+		},
+	})
+
+	result, err = GenerateObject[Answer](context.Background(), client, WithProvider(ProviderAnthropic))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Object.Value != 7 {
+		t.Errorf("Expected 7, got %d", result.Object.Value)
+	}
+}
+
+func TestObjectInvalidType(t *testing.T) {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockProvider{
+		GetObjectFunc: func(ctx context.Context, config *Config, target interface{}) error {
+			t.Fatalf("Expected no request for an invalid type")
+			return nil
+		},
+	})
+
+	if _, err := Object[chan int](context.Background(), client); !errors.Is(err, ErrInvalidObjectTarget) {
+		t.Errorf("Expected ErrInvalidObjectTarget for chan, got %v", err)
+	}
+	if _, err := Object[func()](context.Background(), client); !errors.Is(err, ErrInvalidObjectTarget) {
+		t.Errorf("Expected ErrInvalidObjectTarget for func, got %v", err)
+	}
+	if _, err := Object[error](context.Background(), client); !errors.Is(err, ErrInvalidObjectTarget) {
+		t.Errorf("Expected ErrInvalidObjectTarget for interface, got %v", err)
+	}
+
+	// GetObject rejects targets that are not pointers
+	var value struct{}
+	if err := client.GetObject(context.Background(), value); !errors.Is(err, ErrInvalidObjectTarget) {
+		t.Errorf("Expected ErrInvalidObjectTarget for non-pointer, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+// Package otelai instruments go-ai-sdk with OpenTelemetry tracing and metrics,
+// following the semantic conventions for generative AI systems.
+//
+// Middleware creates a span and records metrics for every call a client makes to a provider:
+//
+//	client.Use(otelai.Middleware())
+//
+// Transport creates a child span for each HTTP request a provider sends, including retries:
+//
+//	provider := openai.New(openai.WithHTTPClient(&http.Client{Transport: otelai.Transport(nil)}))
+package otelai
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+// instrumentationName identifies this package as the source of spans and metrics
+const instrumentationName = "github.com/gnfisher/go-ai-sdk/otelai"
+
+// Attributes added by conventions newer than the semconv package supported by Go 1.22
+const (
+	providerNameKey  = attribute.Key("gen_ai.provider.name") // replaces gen_ai.system
+	outputTypeKey    = attribute.Key("gen_ai.output.type")
+	requestStreamKey = attribute.Key("gen_ai.request.stream")
+)
+
+// durationBuckets and tokenBuckets are the histogram boundaries recommended by the conventions
+var (
+	durationBuckets = []float64{0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92}
+	tokenBuckets    = []float64{1, 4, 16, 64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864}
+)
+
+// config holds the providers used to create spans and metrics
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagators    propagation.TextMapPropagator
+}
+
+// Option is a function that configures the instrumentation
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider (defaults to the global provider)
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider (defaults to the global provider)
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// WithPropagators sets the propagators Transport uses to inject the trace context
+// into outgoing requests (defaults to the global propagators)
+func WithPropagators(propagators propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagators = propagators
+	}
+}
+
+func newConfig(options []Option) *config {
+	c := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagators:    otel.GetTextMapPropagator(),
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c
+}
+
+// Middleware returns client middleware that traces each provider call in a span named
+// after the operation and model, and records the gen_ai.client.operation.duration and
+// gen_ai.client.token.usage histograms. Streams are measured until they end.
+func Middleware(options ...Option) ai.Middleware {
+	c := newConfig(options)
+
+	tracer := c.tracerProvider.Tracer(instrumentationName)
+	meter := c.meterProvider.Meter(instrumentationName)
+
+	// Instrument creation only fails for invalid names, so the no-op fallbacks are never used in practice
+	duration, err := meter.Float64Histogram("gen_ai.client.operation.duration",
+		metric.WithDescription("Duration of GenAI client operations"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	tokens, err := meter.Int64Histogram("gen_ai.client.token.usage",
+		metric.WithDescription("Number of input and output tokens used"),
+		metric.WithUnit("{token}"),
+		metric.WithExplicitBucketBoundaries(tokenBuckets...),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return func(next ai.Handler) ai.Handler {
+		return func(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+			attrs := requestAttributes(req)
+			start := time.Now()
+
+			ctx, span := tracer.Start(ctx, spanName(req),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+
+			finish := func(result *ai.Result, err error) {
+				metricAttrs := attrs
+				if result != nil {
+					span.SetAttributes(resultAttributes(result)...)
+					if result.Model != "" {
+						metricAttrs = append(metricAttrs, semconv.GenAIResponseModel(result.Model))
+					}
+				}
+				if err != nil {
+					errType := errorType(err)
+					span.SetAttributes(errType)
+					span.RecordError(err)
+					span.SetStatus(codes.Error, err.Error())
+					metricAttrs = append(metricAttrs, errType)
+				}
+				span.End()
+
+				if duration != nil {
+					duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(metricAttrs...))
+				}
+				if tokens != nil && hasUsage(result) && err == nil {
+					tokens.Record(ctx, int64(result.Usage.InputTokens), metric.WithAttributes(append(metricAttrs, semconv.GenAITokenTypeInput)...))
+					tokens.Record(ctx, int64(result.Usage.OutputTokens), metric.WithAttributes(append(metricAttrs, semconv.GenAITokenTypeCompletion)...))
+				}
+			}
+
+			resp, err := next(ctx, req)
+			if err == nil && resp.Stream != nil {
+				resp.Stream.OnFinish(finish)
+				return resp, nil
+			}
+
+			var result *ai.Result
+			if resp != nil {
+				result = resp.Result
+			}
+			finish(result, err)
+			return resp, err
+		}
+	}
+}
+
+// spanName follows the "{gen_ai.operation.name} {gen_ai.request.model}" convention
+func spanName(req *ai.Request) string {
+	if req.Config.Model == "" {
+		return "chat"
+	}
+	return "chat " + req.Config.Model
+}
+
+// requestAttributes describes a request
+func requestAttributes(req *ai.Request) []attribute.KeyValue {
+	config := req.Config
+	attrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameChat,
+		providerNameKey.String(string(config.Provider)),
+		semconv.GenAISystemKey.String(string(config.Provider)),
+		semconv.GenAIRequestModel(config.Model),
+	}
+
+	if config.MaxTokens > 0 {
+		attrs = append(attrs, semconv.GenAIRequestMaxTokens(config.MaxTokens))
+	}
+	attrs = append(attrs, semconv.GenAIRequestTemperature(config.Temperature))
+
+	switch req.Kind {
+	case ai.RequestObject, ai.RequestObjectStream:
+		attrs = append(attrs, outputTypeKey.String("json"))
+	default:
+		attrs = append(attrs, outputTypeKey.String("text"))
+	}
+	if req.Kind == ai.RequestStream || req.Kind == ai.RequestObjectStream {
+		attrs = append(attrs, requestStreamKey.Bool(true))
+	}
+
+	return attrs
+}
+
+// resultAttributes describes a response
+func resultAttributes(result *ai.Result) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if hasUsage(result) {
+		attrs = append(attrs,
+			semconv.GenAIUsageInputTokens(result.Usage.InputTokens),
+			semconv.GenAIUsageOutputTokens(result.Usage.OutputTokens),
+		)
+	}
+	if result.FinishReason != "" {
+		attrs = append(attrs, semconv.GenAIResponseFinishReasons(string(result.FinishReason)))
+	}
+	if result.ID != "" {
+		attrs = append(attrs, semconv.GenAIResponseID(result.ID))
+	}
+	if result.Model != "" {
+		attrs = append(attrs, semconv.GenAIResponseModel(result.Model))
+	}
+	return attrs
+}
+
+// hasUsage reports whether a result has token usage
+func hasUsage(result *ai.Result) bool {
+	return result != nil && (result.Usage.InputTokens > 0 || result.Usage.OutputTokens > 0)
+}
+
+// errorType identifies an error by the provider's error code or type where there is one
+func errorType(err error) attribute.KeyValue {
+	var apiErr *ai.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code != "":
+			return semconv.ErrorTypeKey.String(apiErr.Code)
+		case apiErr.Type != "":
+			return semconv.ErrorTypeKey.String(apiErr.Type)
+		case apiErr.StatusCode != 0:
+			return semconv.ErrorTypeKey.String(strconv.Itoa(apiErr.StatusCode))
+		}
+	}
+	return goErrorType(err)
+}
+
+// goErrorType identifies an error by its Go type, or _OTHER for unnamed types
+func goErrorType(err error) attribute.KeyValue {
+	t := reflect.TypeOf(err)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.PkgPath() == "" || t.Name() == "" {
+		return semconv.ErrorTypeKey.String("_OTHER")
+	}
+	return semconv.ErrorTypeKey.String(t.PkgPath() + "." + t.Name())
+}
@@ -0,0 +1,230 @@
+package otelai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/gnfisher/go-ai-sdk"
+	"github.com/gnfisher/go-ai-sdk/providers/openai"
+)
+
+// setup returns a client calling an OpenAI test server through the instrumentation
+func setup(t *testing.T, handler http.HandlerFunc) (*ai.Client, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	provider := openai.New(
+		openai.WithAPIKey("test-key"),
+		openai.WithAPIURL(server.URL),
+		openai.WithHTTPClient(&http.Client{Transport: Transport(nil,
+			WithTracerProvider(tracerProvider),
+			WithPropagators(propagation.TraceContext{}),
+		)}),
+	)
+
+	client := ai.NewClient(
+		ai.WithProvider(ai.ProviderOpenAI),
+		ai.WithModel("gpt-4o"),
+		ai.WithMaxTokens(100),
+		ai.WithTemperature(0.5),
+	)
+	client.RegisterProvider(ai.ProviderOpenAI, provider)
+	client.Use(Middleware(WithTracerProvider(tracerProvider), WithMeterProvider(meterProvider)))
+
+	return client, recorder, reader
+}
+
+// attributes returns the attributes of a span by key
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, attr := range span.Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+func TestMiddleware(t *testing.T) {
+	var traceparent string
+	client, recorder, reader := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{
+			"id": "chatcmpl-123",
+			"model": "gpt-4o-2024-08-06",
+			"choices": [{"message": {"role": "assistant", "content": "Hello!"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 3, "total_tokens": 15}
+		}`))
+	})
+
+	if _, err := client.GenerateText(context.Background(), ai.WithMessages(ai.UserMessage("Hi"))); err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	httpSpan, chatSpan := spans[0], spans[1]
+
+	if chatSpan.Name() != "chat gpt-4o" {
+		t.Errorf("Expected span name chat gpt-4o, got %s", chatSpan.Name())
+	}
+	attrs := attributes(chatSpan)
+	expected := map[attribute.Key]interface{}{
+		"gen_ai.operation.name":          "chat",
+		"gen_ai.provider.name":           "openai",
+		"gen_ai.system":                  "openai",
+		"gen_ai.request.model":           "gpt-4o",
+		"gen_ai.request.max_tokens":      int64(100),
+		"gen_ai.request.temperature":     0.5,
+		"gen_ai.response.id":             "chatcmpl-123",
+		"gen_ai.response.model":          "gpt-4o-2024-08-06",
+		"gen_ai.usage.input_tokens":      int64(12),
+		"gen_ai.usage.output_tokens":     int64(3),
+		"gen_ai.response.finish_reasons": []string{"stop"},
+	}
+	for key, want := range expected {
+		value, ok := attrs[key]
+		if !ok {
+			t.Errorf("Missing attribute %s", key)
+			continue
+		}
+		if got := value.Emit(); got != anyAttr(key, want).Value.Emit() {
+			t.Errorf("Expected %s = %v, got %s", key, want, got)
+		}
+	}
+
+	// The HTTP call is a child of the chat span and carries its trace context
+	if httpSpan.Parent().SpanID() != chatSpan.SpanContext().SpanID() {
+		t.Errorf("Expected the HTTP span to be a child of the chat span")
+	}
+	if got := attributes(httpSpan)["http.response.status_code"].AsInt64(); got != http.StatusOK {
+		t.Errorf("Expected status code 200, got %d", got)
+	}
+	if traceparent == "" || traceparent[3:35] != chatSpan.SpanContext().TraceID().String() {
+		t.Errorf("Expected the trace context to be propagated, got %q", traceparent)
+	}
+
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &metrics); err != nil {
+		t.Fatal(err)
+	}
+	histograms := make(map[string]metricdata.Histogram[float64])
+	tokens := make(map[string]int64)
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				histograms[m.Name] = data
+			case metricdata.Histogram[int64]:
+				for _, point := range data.DataPoints {
+					tokenType, _ := point.Attributes.Value("gen_ai.token.type")
+					tokens[tokenType.AsString()] += point.Sum
+				}
+			}
+		}
+	}
+	if duration := histograms["gen_ai.client.operation.duration"]; len(duration.DataPoints) != 1 || duration.DataPoints[0].Count != 1 {
+		t.Errorf("Expected one duration measurement, got %+v", duration.DataPoints)
+	}
+	if tokens["input"] != 12 || tokens["output"] != 3 {
+		t.Errorf("Expected 12 input and 3 output tokens, got %v", tokens)
+	}
+}
+
+// anyAttr builds an attribute from a Go value
+func anyAttr(key attribute.Key, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return key.String(v)
+	case int64:
+		return key.Int64(v)
+	case float64:
+		return key.Float64(v)
+	case []string:
+		return key.StringSlice(v)
+	}
+	panic("unsupported attribute value")
+}
+
+func TestMiddlewareError(t *testing.T) {
+	client, recorder, _ := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"message": "Rate limit reached", "type": "requests", "code": "rate_limit_exceeded"}}`))
+	})
+
+	_, err := client.GetText(context.Background(), ai.WithMessages(ai.UserMessage("Hi")))
+	if !errors.Is(err, ai.ErrRateLimited) {
+		t.Fatalf("Expected a rate limit error, got %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	for _, span := range spans {
+		if span.Status().Code != codes.Error {
+			t.Errorf("Expected span %s to have an error status", span.Name())
+		}
+	}
+	if got := attributes(spans[1])["error.type"].AsString(); got != "rate_limit_exceeded" {
+		t.Errorf("Expected error.type rate_limit_exceeded, got %s", got)
+	}
+	if got := attributes(spans[0])["error.type"].AsString(); got != "429" {
+		t.Errorf("Expected error.type 429 on the HTTP span, got %s", got)
+	}
+}
+
+func TestMiddlewareStream(t *testing.T) {
+	client, recorder, _ := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"id\":\"chatcmpl-1\",\"model\":\"gpt-4o\",\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n"))
+		w.Write([]byte("data: {\"id\":\"chatcmpl-1\",\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":1}}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	})
+
+	stream, err := client.StreamText(context.Background(), ai.WithMessages(ai.UserMessage("Hi")))
+	if err != nil {
+		t.Fatalf("StreamText failed: %v", err)
+	}
+
+	// The chat span stays open until the stream ends
+	for _, span := range recorder.Ended() {
+		if span.Name() == "chat gpt-4o" {
+			t.Fatal("Expected the chat span to be open while streaming")
+		}
+	}
+
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	spans := recorder.Ended()
+	chatSpan := spans[len(spans)-1]
+	attrs := attributes(chatSpan)
+	if chatSpan.Name() != "chat gpt-4o" || !attrs["gen_ai.request.stream"].AsBool() {
+		t.Fatalf("Expected a streaming chat span, got %s %v", chatSpan.Name(), attrs)
+	}
+	if attrs["gen_ai.usage.output_tokens"].AsInt64() != 1 {
+		t.Errorf("Expected 1 output token, got %v", attrs["gen_ai.usage.output_tokens"])
+	}
+}
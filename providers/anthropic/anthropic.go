@@ -3,14 +3,18 @@ package anthropic
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gnfisher/go-ai-sdk"
+	"github.com/gnfisher/go-ai-sdk/internal/retry"
 )
 
 const (
@@ -28,6 +32,8 @@ type Provider struct {
 	apiKey string
 	apiURL string
 	client *http.Client
+	retry  ai.RetryPolicy
+	logger *slog.Logger
 }
 
 // Option is a function that configures the Anthropic provider
@@ -54,6 +60,22 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithRetry sets the policy for retrying requests that fail with a transient error.
+// It can be overridden per request with ai.WithRetry.
+func WithRetry(policy ai.RetryPolicy) Option {
+	return func(p *Provider) {
+		p.retry = policy
+	}
+}
+
+// WithLogger sets the logger for HTTP requests and retries.
+// It can be overridden per request with ai.WithLogger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger
+	}
+}
+
 // New creates a new Anthropic provider
 func New(options ...Option) *Provider {
 	provider := &Provider{
@@ -68,25 +90,63 @@ func New(options ...Option) *Provider {
 	return provider
 }
 
-// Message represents an Anthropic message
+// Message represents an Anthropic message.
+// Content is either a string or a slice of Content blocks.
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+// Tool represents a tool definition in an Anthropic request
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
 }
 
 // Request represents a request to the Anthropic API
 type Request struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Temperature float64   `json:"temperature,omitempty"`
-	System      string    `json:"system,omitempty"`
+	Model       string      `json:"model"`
+	Messages    []Message   `json:"messages"`
+	MaxTokens   int         `json:"max_tokens,omitempty"`
+	Temperature float64     `json:"temperature,omitempty"`
+	System      string      `json:"system,omitempty"`
+	Tools       []Tool      `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice `json:"tool_choice,omitempty"`
+	Stream      bool        `json:"stream,omitempty"`
+}
+
+// ToolChoice controls how the model uses the provided tools
+type ToolChoice struct {
+	Type string `json:"type"` // "auto", "any", "tool" or "none"
+	Name string `json:"name,omitempty"`
 }
 
-// Content represents content in the Anthropic API response
+// Content represents a content block in Anthropic messages and responses
 type Content struct {
 	Type string `json:"type"`
-	Text string `json:"text"`
+	Text string `json:"text,omitempty"`
+
+	// Fields for tool_use blocks
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// Fields for tool_result blocks
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+
+	// Fields for image and document blocks
+	Source *Source `json:"source,omitempty"`
+	Title  string  `json:"title,omitempty"`
+}
+
+// Source holds the data of an image or document block
+type Source struct {
+	Type      string `json:"type"` // "base64", "url" or "text"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
 }
 
 // Response represents a response from the Anthropic API
@@ -97,15 +157,29 @@ type Response struct {
 	Content    []Content `json:"content"`
 	Model      string    `json:"model"`
 	StopReason string    `json:"stop_reason"`
+	Usage      *Usage    `json:"usage,omitempty"`
 	Error      *Error    `json:"error,omitempty"`
 }
 
+// Usage represents token usage in the Anthropic API response
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+
+	// Tokens written to and read from the prompt cache, which are not counted in InputTokens
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
 // Error represents an error in the Anthropic API response
 type Error struct {
 	Type    string `json:"type"`
 	Message string `json:"message"`
 }
 
+// emptyInputSchema is used for tools that take no parameters, since Anthropic requires an input schema
+var emptyInputSchema = json.RawMessage(`{"type":"object","properties":{}}`)
+
 // convertMessages converts ai.Message to anthropic.Message and extracts system message
 func convertMessages(messages []ai.Message) ([]Message, string) {
 	var systemMessage string
@@ -113,7 +187,30 @@ func convertMessages(messages []ai.Message) ([]Message, string) {
 
 	for _, msg := range messages {
 		if msg.Role == ai.RoleSystem {
-			systemMessage = msg.Content
+			systemMessage = messageText(msg)
+			continue
+		}
+
+		// Tool results are sent as tool_result blocks in a user message,
+		// and results for the same assistant turn must share one message
+		if msg.Role == ai.RoleTool {
+			block := Content{
+				Type:      "tool_result",
+				ToolUseID: msg.ToolCallID,
+				Content:   messageText(msg),
+			}
+
+			if n := len(result); n > 0 && result[n-1].Role == "user" {
+				if blocks, ok := result[n-1].Content.([]Content); ok {
+					result[n-1].Content = append(blocks, block)
+					continue
+				}
+			}
+
+			result = append(result, Message{
+				Role:    "user",
+				Content: []Content{block},
+			})
 			continue
 		}
 
@@ -125,170 +222,378 @@ func convertMessages(messages []ai.Message) ([]Message, string) {
 			role = "user"
 		}
 
+		if len(msg.Parts) == 0 && len(msg.ToolCalls) == 0 {
+			result = append(result, Message{
+				Role:    role,
+				Content: msg.Content,
+			})
+			continue
+		}
+
+		// Assistant tool calls are sent as tool_use blocks following any parts or text
+		var blocks []Content
+		if len(msg.Parts) > 0 {
+			blocks = convertParts(msg.Parts)
+		} else if msg.Content != "" {
+			blocks = append(blocks, Content{Type: "text", Text: msg.Content})
+		}
+		for _, call := range msg.ToolCalls {
+			input := call.Arguments
+			if len(input) == 0 {
+				input = json.RawMessage(`{}`)
+			}
+			blocks = append(blocks, Content{
+				Type:  "tool_use",
+				ID:    call.ID,
+				Name:  call.Name,
+				Input: input,
+			})
+		}
+
 		result = append(result, Message{
 			Role:    role,
-			Content: msg.Content,
+			Content: blocks,
 		})
 	}
 
 	return result, systemMessage
 }
 
-// GetText gets a text response from the Anthropic API
-func (p *Provider) GetText(ctx context.Context, config *ai.Config) (string, error) {
-	if p.apiKey == "" {
-		return "", ErrEmptyAPIKey
+// messageText returns the text of a message, joining its text parts if it has any
+func messageText(msg ai.Message) string {
+	if len(msg.Parts) == 0 {
+		return msg.Content
+	}
+
+	var texts []string
+	for _, part := range msg.Parts {
+		if part.Type == ai.PartTypeText {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// convertParts converts ai.Part to anthropic.Content blocks. The parts must have passed checkContent.
+func convertParts(parts []ai.Part) []Content {
+	result := make([]Content, len(parts))
+	for i, part := range parts {
+		switch part.Type {
+		case ai.PartTypeText:
+			result[i] = Content{Type: "text", Text: part.Text}
+		case ai.PartTypeImage:
+			result[i] = Content{Type: "image", Source: convertSource(part)}
+		case ai.PartTypeDocument:
+			result[i] = Content{Type: "document", Source: convertSource(part), Title: part.Name}
+		}
+	}
+	return result
+}
+
+// convertSource converts the data or URL of an image or document part
+func convertSource(part ai.Part) *Source {
+	if part.URL != "" {
+		return &Source{Type: "url", URL: part.URL}
+	}
+
+	// Plain text documents are sent as text rather than base64
+	if part.MediaType == "text/plain" {
+		text, _ := base64.StdEncoding.DecodeString(part.Data)
+		return &Source{Type: "text", MediaType: part.MediaType, Data: string(text)}
+	}
+
+	return &Source{Type: "base64", MediaType: part.MediaType, Data: part.Data}
+}
+
+// imageMediaTypes are the image formats accepted by the Anthropic API
+var imageMediaTypes = map[string]bool{
+	"image/jpeg": true, "image/png": true, "image/gif": true, "image/webp": true,
+}
+
+// checkContent reports an error if the messages hold content parts Anthropic cannot accept
+func checkContent(messages []ai.Message) error {
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			if part.Type != ai.PartTypeText && msg.Role != ai.RoleUser {
+				return fmt.Errorf("%w: Anthropic only accepts %s parts in user messages", ai.ErrUnsupportedContent, part.Type)
+			}
+
+			switch part.Type {
+			case ai.PartTypeText:
+			case ai.PartTypeImage:
+				if part.URL == "" && !imageMediaTypes[part.MediaType] {
+					return fmt.Errorf("%w: Anthropic does not accept %q images", ai.ErrUnsupportedContent, part.MediaType)
+				}
+			case ai.PartTypeDocument:
+				if part.URL == "" && part.MediaType != "application/pdf" && part.MediaType != "text/plain" {
+					return fmt.Errorf("%w: Anthropic does not accept %q documents", ai.ErrUnsupportedContent, part.MediaType)
+				}
+				if part.MediaType == "text/plain" {
+					if _, err := base64.StdEncoding.DecodeString(part.Data); err != nil {
+						return fmt.Errorf("%w: invalid document data: %v", ai.ErrUnsupportedContent, err)
+					}
+				}
+			default:
+				return fmt.Errorf("%w: unknown part type %q", ai.ErrUnsupportedContent, part.Type)
+			}
+		}
 	}
+	return nil
+}
 
+// convertTools converts ai.Tool to anthropic.Tool
+func convertTools(tools []ai.Tool) []Tool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	result := make([]Tool, len(tools))
+	for i, tool := range tools {
+		schema := tool.Parameters
+		if len(schema) == 0 {
+			schema = emptyInputSchema
+		}
+
+		result[i] = Tool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: schema,
+		}
+	}
+	return result
+}
+
+// convertContent collects the text and tool calls from Anthropic response content
+func convertContent(content []Content) *ai.Result {
+	result := &ai.Result{}
+	for _, block := range content {
+		switch block.Type {
+		case "text":
+			result.Text += block.Text
+		case "tool_use":
+			result.ToolCalls = append(result.ToolCalls, ai.ToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: block.Input,
+			})
+		}
+	}
+	return result
+}
+
+// newRequest builds an Anthropic request from the config
+func newRequest(config *ai.Config) Request {
 	anthropicMessages, systemMessage := convertMessages(config.Messages)
 
-	reqBody := Request{
+	return Request{
 		Model:       config.Model,
 		Messages:    anthropicMessages,
 		Temperature: config.Temperature,
 		MaxTokens:   config.MaxTokens,
 		System:      systemMessage,
+		Tools:       convertTools(config.Tools),
 	}
+}
 
+// do posts a request to the Anthropic Messages API and returns the response if it succeeded.
+// The caller must close the response body.
+func (p *Provider) do(ctx context.Context, config *ai.Config, reqBody Request) (*http.Response, error) {
+	return p.post(ctx, config, p.apiURL, reqBody)
+}
+
+// post posts a request body to an Anthropic API endpoint and returns the response if it succeeded.
+// The caller must close the response body.
+func (p *Provider) post(ctx context.Context, config *ai.Config, url string, reqBody interface{}) (*http.Response, error) {
 	reqJSON, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewBuffer(reqJSON))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
+	logger := p.loggerFor(config)
+	start := time.Now()
+	var sent *http.Request
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", p.apiKey)
-	req.Header.Set("anthropic-version", anthropicVersion)
+	resp, err := retry.Do(ctx, p.client, p.retryPolicy(config), logger, nil, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqJSON))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
 
-	resp, err := p.client.Do(req)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-api-key", p.apiKey)
+		req.Header.Set("anthropic-version", anthropicVersion)
+		sent = req
+		return req, nil
+	})
+	retry.LogResponse(ctx, logger, sent, resp, err, time.Since(start))
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+	if config.RateLimitObserver != nil {
+		if status, ok := retry.RateLimits(resp.Header, time.Now()); ok {
+			config.RateLimitObserver(status)
+		}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		var errResp Response
-		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
-			return "", fmt.Errorf("Anthropic API error: %s", errResp.Error.Message)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
 		}
-		return "", fmt.Errorf("Anthropic API returned status code %d: %s", resp.StatusCode, body)
+
+		return nil, newAPIError(resp, body)
 	}
 
-	var anthropicResp Response
-	if err := json.Unmarshal(body, &anthropicResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	return resp, nil
+}
+
+// convertStopReason converts an Anthropic stop_reason to ai.FinishReason
+func convertStopReason(reason string) ai.FinishReason {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return ai.FinishReasonStop
+	case "max_tokens":
+		return ai.FinishReasonLength
+	case "tool_use":
+		return ai.FinishReasonToolCalls
+	case "refusal":
+		return ai.FinishReasonContentFilter
+	case "":
+		return ""
+	default:
+		return ai.FinishReasonOther
 	}
+}
 
-	if len(anthropicResp.Content) == 0 || anthropicResp.Content[0].Text == "" {
-		return "", ErrInvalidResponse
+// convertUsage converts Anthropic usage to ai.Usage
+func convertUsage(usage *Usage) ai.Usage {
+	if usage == nil {
+		return ai.Usage{}
 	}
+	return ai.Usage{
+		InputTokens:       usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens,
+		OutputTokens:      usage.OutputTokens,
+		CachedInputTokens: usage.CacheReadInputTokens,
+	}
+}
 
-	return anthropicResp.Content[0].Text, nil
+// loggerFor returns the logger for a request, or nil if it is not logged
+func (p *Provider) loggerFor(config *ai.Config) *slog.Logger {
+	logger := p.logger
+	if config.Logger != nil {
+		logger = config.Logger
+	}
+	if logger == nil {
+		return nil
+	}
+	return logger.With(slog.String("provider", string(ai.ProviderAnthropic)))
 }
 
-// GetObject gets a structured response from the Anthropic API
-func (p *Provider) GetObject(ctx context.Context, config *ai.Config, target interface{}) error {
-	if p.apiKey == "" {
-		return ErrEmptyAPIKey
+// retryPolicy returns the retry policy for a request
+func (p *Provider) retryPolicy(config *ai.Config) ai.RetryPolicy {
+	if config.Retry != nil {
+		return *config.Retry
 	}
+	return p.retry
+}
 
-	// Extract the type information from the target
-	targetType := fmt.Sprintf("%T", target)
+// send posts a request to the Anthropic API and decodes the response
+func (p *Provider) send(ctx context.Context, config *ai.Config, reqBody Request) (*Response, error) {
+	resp, err := p.do(ctx, config, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
 
-	// Create a system message instructing the model to return JSON
-	systemMsg := fmt.Sprintf("You are a helpful assistant that responds with JSON matching the %s type. Your response should be valid JSON and nothing else.", targetType)
+	var anthropicResp Response
+	if err := json.Unmarshal(body, &anthropicResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
 
-	// Prepare messages
-	messages := config.Messages
-	anthropicMessages, existingSystemMsg := convertMessages(messages)
+	return &anthropicResp, nil
+}
 
-	// If there's already a system message, append our JSON instruction
-	if existingSystemMsg != "" {
-		systemMsg = existingSystemMsg + "\n\n" + systemMsg
+// GenerateText gets a full result, including any tool calls, from the Anthropic API
+func (p *Provider) GenerateText(ctx context.Context, config *ai.Config) (*ai.Result, error) {
+	if p.apiKey == "" {
+		return nil, ErrEmptyAPIKey
 	}
 
-	// Get the text response
-	reqBody := Request{
-		Model:       config.Model,
-		Messages:    anthropicMessages,
-		Temperature: config.Temperature,
-		MaxTokens:   config.MaxTokens,
-		System:      systemMsg,
+	if err := checkContent(config.Messages); err != nil {
+		return nil, err
 	}
 
-	reqJSON, err := json.Marshal(reqBody)
+	anthropicResp, err := p.send(ctx, config, newRequest(config))
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewBuffer(reqJSON))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	result := convertContent(anthropicResp.Content)
+	if result.Text == "" && len(result.ToolCalls) == 0 {
+		return nil, ErrInvalidResponse
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", p.apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
+	result.FinishReason = convertStopReason(anthropicResp.StopReason)
+	result.Usage = convertUsage(anthropicResp.Usage)
+	result.ID = anthropicResp.ID
+	result.Model = anthropicResp.Model
 
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+	return result, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// GetText gets a text response from the Anthropic API
+func (p *Provider) GetText(ctx context.Context, config *ai.Config) (string, error) {
+	result, err := p.GenerateText(ctx, config)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return "", err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		var errResp Response
-		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
-			return fmt.Errorf("Anthropic API error: %s", errResp.Error.Message)
-		}
-		return fmt.Errorf("Anthropic API returned status code %d: %s", resp.StatusCode, body)
+	if result.Text == "" {
+		return "", ErrInvalidResponse
 	}
 
-	var anthropicResp Response
-	if err := json.Unmarshal(body, &anthropicResp); err != nil {
-		return fmt.Errorf("failed to unmarshal response: %w", err)
-	}
+	return result.Text, nil
+}
 
-	if len(anthropicResp.Content) == 0 || anthropicResp.Content[0].Text == "" {
-		return ErrInvalidResponse
-	}
+// GetObject gets a structured response from the Anthropic API
+func (p *Provider) GetObject(ctx context.Context, config *ai.Config, target interface{}) error {
+	_, err := p.GenerateObject(ctx, config, target)
+	return err
+}
 
-	// Clean the response to ensure it's valid JSON
-	jsonStr := strings.TrimSpace(anthropicResp.Content[0].Text)
+// GenerateObject gets a structured response from the Anthropic API along with its metadata
+func (p *Provider) GenerateObject(ctx context.Context, config *ai.Config, target interface{}) (*ai.Result, error) {
+	if p.apiKey == "" {
+		return nil, ErrEmptyAPIKey
+	}
 
-	// If response starts with ``` (markdown code block), clean it up
-	if strings.HasPrefix(jsonStr, "```json") {
-		jsonStr = strings.TrimPrefix(jsonStr, "```json")
-		if idx := strings.LastIndex(jsonStr, "```"); idx != -1 {
-			jsonStr = jsonStr[:idx]
-		}
-	} else if strings.HasPrefix(jsonStr, "```") {
-		jsonStr = strings.TrimPrefix(jsonStr, "```")
-		if idx := strings.LastIndex(jsonStr, "```"); idx != -1 {
-			jsonStr = jsonStr[:idx]
-		}
+	if err := checkContent(config.Messages); err != nil {
+		return nil, err
 	}
 
-	jsonStr = strings.TrimSpace(jsonStr)
+	var result *ai.Result
+	var err error
 
-	// Unmarshal the JSON into the target
-	if err := json.Unmarshal([]byte(jsonStr), target); err != nil {
-		return fmt.Errorf("failed to unmarshal JSON response: %w: %s", err, jsonStr)
+	switch config.ObjectMode {
+	case ai.ObjectModeAuto, ai.ObjectModeTool, ai.ObjectModeSchema:
+		result, err = p.generateObjectWithTool(ctx, config, target)
+	case ai.ObjectModePrompt:
+		result, err = p.generateObjectWithPrompt(ctx, config, target)
+	default:
+		err = fmt.Errorf("%w: %s", ai.ErrObjectModeNotSupported, config.ObjectMode)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	// Decode and validate the response
+	return result, ai.DecodeObject(result.Text, target)
 }
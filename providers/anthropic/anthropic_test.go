@@ -3,9 +3,13 @@ package anthropic
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gnfisher/go-ai-sdk"
 )
@@ -336,3 +340,320 @@ func TestProviderOptions(t *testing.T) {
 		t.Errorf("Expected client to be the custom client")
 	}
 }
+
+func TestGenerateTextWithTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Tools []Tool `json:"tools"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+
+		if len(req.Tools) != 1 || req.Tools[0].Name != "get_weather" {
+			t.Errorf("Expected get_weather tool, got %+v", req.Tools)
+		}
+
+		resp := Response{
+			ID:   "msg_123",
+			Type: "message",
+			Role: "assistant",
+			Content: []Content{
+				{Type: "text", Text: "Let me check."},
+				{Type: "tool_use", ID: "toolu_1", Name: "get_weather", Input: json.RawMessage(`{"city":"Paris"}`)},
+			},
+			StopReason: "tool_use",
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Fatalf("failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	result, err := provider.GenerateText(context.Background(), &ai.Config{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []ai.Message{ai.UserMessage("What's the weather in Paris?")},
+		Tools: []ai.Tool{
+			{Name: "get_weather", Description: "Get the weather for a city"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Text != "Let me check." {
+		t.Errorf("Expected text 'Let me check.', got %q", result.Text)
+	}
+
+	if len(result.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(result.ToolCalls))
+	}
+
+	call := result.ToolCalls[0]
+	if call.ID != "toolu_1" || call.Name != "get_weather" || string(call.Arguments) != `{"city":"Paris"}` {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+}
+
+func TestConvertMessagesWithToolCalls(t *testing.T) {
+	messages, _ := convertMessages([]ai.Message{
+		ai.UserMessage("What's the weather in Paris and London?"),
+		ai.AssistantToolCallMessage("",
+			ai.ToolCall{ID: "toolu_1", Name: "get_weather", Arguments: json.RawMessage(`{"city":"Paris"}`)},
+			ai.ToolCall{ID: "toolu_2", Name: "get_weather", Arguments: json.RawMessage(`{"city":"London"}`)},
+		),
+		ai.ToolResultMessage("toolu_1", "Sunny"),
+		ai.ToolResultMessage("toolu_2", "Rainy"),
+	})
+
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+
+	toolUse, ok := messages[1].Content.([]Content)
+	if !ok || len(toolUse) != 2 || toolUse[0].Type != "tool_use" || toolUse[1].ID != "toolu_2" {
+		t.Errorf("Unexpected tool_use content: %+v", messages[1].Content)
+	}
+
+	// Both results must be merged into a single user message
+	toolResults, ok := messages[2].Content.([]Content)
+	if messages[2].Role != "user" || !ok || len(toolResults) != 2 {
+		t.Fatalf("Expected one user message with 2 tool results, got %+v", messages[2])
+	}
+
+	if toolResults[0].ToolUseID != "toolu_1" || toolResults[1].Content != "Rainy" {
+		t.Errorf("Unexpected tool_result content: %+v", toolResults)
+	}
+}
+
+func TestGenerateTextMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := Response{
+			ID:         "msg_123",
+			Type:       "message",
+			Role:       "assistant",
+			Content:    []Content{{Type: "text", Text: "Once upon a"}},
+			Model:      "claude-3-haiku-20240307",
+			StopReason: "max_tokens",
+			Usage:      &Usage{InputTokens: 10, OutputTokens: 3},
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Fatalf("failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	result, err := provider.GenerateText(context.Background(), &ai.Config{
+		Model:    "claude-3-haiku",
+		Messages: []ai.Message{ai.UserMessage("Tell me a story")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.FinishReason != ai.FinishReasonLength {
+		t.Errorf("Expected finish reason length, got %s", result.FinishReason)
+	}
+	if result.Usage.InputTokens != 10 || result.Usage.OutputTokens != 3 {
+		t.Errorf("Unexpected usage: %+v", result.Usage)
+	}
+	if result.ID != "msg_123" {
+		t.Errorf("Expected ID msg_123, got %s", result.ID)
+	}
+	if result.Model != "claude-3-haiku-20240307" {
+		t.Errorf("Expected model claude-3-haiku-20240307, got %s", result.Model)
+	}
+}
+
+func TestConvertUsage(t *testing.T) {
+	usage := &Usage{InputTokens: 50, OutputTokens: 100, CacheCreationInputTokens: 200, CacheReadInputTokens: 1000}
+
+	// Cached tokens are counted as input, as they are by OpenAI
+	expected := ai.Usage{InputTokens: 1250, OutputTokens: 100, CachedInputTokens: 1000}
+	if got := convertUsage(usage); got != expected {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestConvertStopReason(t *testing.T) {
+	tests := map[string]ai.FinishReason{
+		"end_turn":      ai.FinishReasonStop,
+		"stop_sequence": ai.FinishReasonStop,
+		"max_tokens":    ai.FinishReasonLength,
+		"tool_use":      ai.FinishReasonToolCalls,
+		"refusal":       ai.FinishReasonContentFilter,
+		"pause_turn":    ai.FinishReasonOther,
+		"":              "",
+	}
+
+	for reason, expected := range tests {
+		if got := convertStopReason(reason); got != expected {
+			t.Errorf("convertStopReason(%q) = %s, want %s", reason, got, expected)
+		}
+	}
+}
+
+func TestGetObjectSchemaPrompt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			System string `json:"system"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+
+		if !strings.HasPrefix(req.System, "You extract people.") {
+			t.Errorf("Expected caller's system message to be kept, got %s", req.System)
+		}
+		if !strings.Contains(req.System, `"required":["name","age"]`) {
+			t.Errorf("Expected system prompt to contain the schema, got %s", req.System)
+		}
+
+		resp := Response{
+			Content: []Content{{Type: "text", Text: `{"name":"Ada","age":36}`}},
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Fatalf("failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var person TestStruct
+	err := provider.GetObject(context.Background(), &ai.Config{
+		Model: "claude-3-haiku-20240307",
+		Messages: []ai.Message{
+			ai.SystemMessage("You extract people."),
+			ai.UserMessage("Ada Lovelace was 36."),
+		},
+		ObjectMode: ai.ObjectModePrompt,
+	}, &person)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if person.Name != "Ada" || person.Age != 36 {
+		t.Errorf("Unexpected person: %+v", person)
+	}
+}
+
+func TestConvertMessagesWithParts(t *testing.T) {
+	messages, system := convertMessages([]ai.Message{
+		{Role: ai.RoleSystem, Parts: []ai.Part{ai.TextPart("Be brief.")}},
+		ai.UserMessageParts(
+			ai.TextPart("Summarize these."),
+			ai.ImageBase64Part("aGVsbG8=", "image/png"),
+			ai.ImageURLPart("https://example.com/cat.jpg"),
+			ai.DocumentPart([]byte("%PDF"), "application/pdf", "Report"),
+			ai.DocumentPart([]byte("Plain notes"), "text/plain", ""),
+		),
+	})
+
+	if system != "Be brief." {
+		t.Errorf("Expected system prompt from text parts, got %q", system)
+	}
+
+	blocks, ok := messages[0].Content.([]Content)
+	if !ok || len(blocks) != 5 {
+		t.Fatalf("Expected 5 content blocks, got %+v", messages[0].Content)
+	}
+
+	expected := []Content{
+		{Type: "text", Text: "Summarize these."},
+		{Type: "image", Source: &Source{Type: "base64", MediaType: "image/png", Data: "aGVsbG8="}},
+		{Type: "image", Source: &Source{Type: "url", URL: "https://example.com/cat.jpg"}},
+		{Type: "document", Source: &Source{Type: "base64", MediaType: "application/pdf", Data: "JVBERg=="}, Title: "Report"},
+		{Type: "document", Source: &Source{Type: "text", MediaType: "text/plain", Data: "Plain notes"}},
+	}
+	for i, block := range blocks {
+		if !reflect.DeepEqual(block, expected[i]) {
+			t.Errorf("Block %d: expected %+v, got %+v", i, expected[i], block)
+		}
+	}
+}
+
+func TestConvertMessagesWithPartsAndToolCalls(t *testing.T) {
+	messages, _ := convertMessages([]ai.Message{
+		{
+			Role:      ai.RoleAssistant,
+			Parts:     []ai.Part{ai.TextPart("Checking the weather.")},
+			ToolCalls: []ai.ToolCall{{ID: "call_1", Name: "get_weather", Arguments: json.RawMessage(`{"city":"Paris"}`)}},
+		},
+	})
+
+	expected := []Content{
+		{Type: "text", Text: "Checking the weather."},
+		{Type: "tool_use", ID: "call_1", Name: "get_weather", Input: json.RawMessage(`{"city":"Paris"}`)},
+	}
+	if !reflect.DeepEqual(messages[0].Content, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, messages[0].Content)
+	}
+}
+
+func TestUnsupportedContent(t *testing.T) {
+	tests := map[string]ai.Message{
+		"image in assistant message": {Role: ai.RoleAssistant, Parts: []ai.Part{ai.ImageURLPart("https://example.com/cat.jpg")}},
+		"unsupported image type":     ai.UserMessageParts(ai.ImageBase64Part("aGVsbG8=", "image/tiff")),
+		"unsupported document type":  ai.UserMessageParts(ai.DocumentPart([]byte("a,b"), "text/csv", "data.csv")),
+	}
+
+	provider := New(WithAPIKey("test-key"), WithAPIURL("http://localhost:0"))
+
+	for name, msg := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := provider.GenerateText(context.Background(), &ai.Config{
+				Model:    "claude-3-haiku-20240307",
+				Messages: []ai.Message{msg},
+			})
+			if !errors.Is(err, ai.ErrUnsupportedContent) {
+				t.Errorf("Expected ErrUnsupportedContent, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("retry-after", "0")
+			w.WriteHeader(529)
+			w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Hello!"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+		WithRetry(ai.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}),
+	)
+
+	text, err := provider.GetText(context.Background(), &ai.Config{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []ai.Message{ai.UserMessage("Hello")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "Hello!" || calls != 2 {
+		t.Errorf("Expected success on the second attempt, got %q after %d calls", text, calls)
+	}
+}
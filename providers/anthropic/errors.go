@@ -0,0 +1,89 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gnfisher/go-ai-sdk"
+	"github.com/gnfisher/go-ai-sdk/internal/retry"
+)
+
+// newAPIError builds the error for a response with a non-200 status code
+func newAPIError(resp *http.Response, body []byte) *ai.APIError {
+	apiErr := &ai.APIError{
+		Provider:   ai.ProviderAnthropic,
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("request-id"),
+		Retryable:  retry.ShouldRetry(resp),
+		Body:       body,
+	}
+
+	var errResp struct {
+		Error     *Error `json:"error"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		if errResp.Error != nil {
+			apiErr.Type = errResp.Error.Type
+			apiErr.Message = errResp.Error.Message
+		}
+		if apiErr.RequestID == "" {
+			apiErr.RequestID = errResp.RequestID
+		}
+	}
+
+	apiErr.Category = errorCategory(apiErr)
+	return apiErr
+}
+
+// streamError builds the error for an error event in the middle of a stream
+func streamError(detail *Error, body []byte) *ai.APIError {
+	apiErr := &ai.APIError{
+		Provider: ai.ProviderAnthropic,
+		Body:     body,
+	}
+	if detail != nil {
+		apiErr.Type = detail.Type
+		apiErr.Message = detail.Message
+	}
+	apiErr.Category = errorCategory(apiErr)
+	apiErr.Retryable = apiErr.Category == ai.ErrRateLimited || apiErr.Category == ai.ErrOverloaded || apiErr.Category == ai.ErrServerError
+	return apiErr
+}
+
+// errorCategory classifies an error by the type reported by the API,
+// falling back to its status code
+func errorCategory(apiErr *ai.APIError) error {
+	switch apiErr.Type {
+	case "authentication_error":
+		return ai.ErrAuthentication
+	case "permission_error":
+		return ai.ErrPermissionDenied
+	case "not_found_error":
+		return ai.ErrNotFound
+	case "rate_limit_error":
+		return ai.ErrRateLimited
+	case "overloaded_error":
+		return ai.ErrOverloaded
+	case "api_error":
+		return ai.ErrServerError
+	case "request_too_large":
+		return ai.ErrInvalidRequest
+	case "invalid_request_error":
+		switch {
+		case strings.Contains(apiErr.Message, "prompt is too long"), strings.Contains(apiErr.Message, "context window"):
+			return ai.ErrContextLengthExceeded
+		case strings.Contains(apiErr.Message, "content filtering"):
+			return ai.ErrContentPolicy
+		case strings.Contains(apiErr.Message, "credit balance"):
+			return ai.ErrQuotaExceeded
+		}
+		return ai.ErrInvalidRequest
+	}
+
+	if category := ai.StatusCategory(apiErr.StatusCode); category != nil {
+		return category
+	}
+	return ai.ErrServerError
+}
@@ -0,0 +1,65 @@
+package anthropic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		category  error
+		retryable bool
+	}{
+		{"authentication", 401, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, ai.ErrAuthentication, false},
+		{"permission", 403, `{"type":"error","error":{"type":"permission_error","message":"not allowed"}}`, ai.ErrPermissionDenied, false},
+		{"not found", 404, `{"type":"error","error":{"type":"not_found_error","message":"model: claude-x"}}`, ai.ErrNotFound, false},
+		{"rate limit", 429, `{"type":"error","error":{"type":"rate_limit_error","message":"Number of requests has exceeded your rate limit"}}`, ai.ErrRateLimited, true},
+		{"overloaded", 529, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, ai.ErrOverloaded, true},
+		{"prompt too long", 400, `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`, ai.ErrContextLengthExceeded, false},
+		{"invalid request", 400, `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: Field required"}}`, ai.ErrInvalidRequest, false},
+		{"server error", 502, `Bad Gateway`, ai.ErrServerError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("request-id", "req_123")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			provider := New(WithAPIKey("test-key"), WithAPIURL(server.URL))
+
+			_, err := provider.GetText(context.Background(), &ai.Config{
+				Model:    "claude-3-haiku-20240307",
+				Messages: []ai.Message{ai.UserMessage("Hello")},
+			})
+
+			var apiErr *ai.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Expected *ai.APIError, got %v", err)
+			}
+			if !errors.Is(err, tt.category) {
+				t.Errorf("Expected %v, got %v", tt.category, apiErr.Category)
+			}
+			if apiErr.Provider != ai.ProviderAnthropic || apiErr.StatusCode != tt.status || apiErr.RequestID != "req_123" {
+				t.Errorf("Unexpected error fields: %+v", apiErr)
+			}
+			if apiErr.Retryable != tt.retryable {
+				t.Errorf("Expected retryable %v, got %v", tt.retryable, apiErr.Retryable)
+			}
+			if string(apiErr.Body) != tt.body {
+				t.Errorf("Expected raw body %s, got %s", tt.body, apiErr.Body)
+			}
+		})
+	}
+}
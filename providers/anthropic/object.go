@@ -0,0 +1,259 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gnfisher/go-ai-sdk"
+	"github.com/gnfisher/go-ai-sdk/internal/partialjson"
+)
+
+const (
+	// objectToolName is the tool the model is forced to call in tool mode
+	objectToolName = "respond_with_object"
+
+	// wrappedValueProperty holds non-object targets, since tool input schemas must be objects
+	wrappedValueProperty = "value"
+)
+
+// objectToolRequest builds a request that forces the model to call a tool whose
+// input schema matches the target. It reports whether a non-object target was wrapped in an object.
+func objectToolRequest(config *ai.Config, target interface{}) (Request, bool, error) {
+	schema, err := ai.SchemaFor(target)
+	if err != nil {
+		return Request{}, false, fmt.Errorf("failed to generate schema: %w", err)
+	}
+
+	wrapped := false
+	if schema.Type != "object" || schema.Properties == nil {
+		schema = &ai.Schema{
+			Type:       "object",
+			Properties: map[string]*ai.Schema{wrappedValueProperty: schema},
+			Required:   []string{wrappedValueProperty},
+		}
+		wrapped = true
+	}
+
+	inputSchema, err := json.Marshal(schema)
+	if err != nil {
+		return Request{}, false, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	anthropicMessages, systemMessage := convertMessages(config.Messages)
+
+	reqBody := Request{
+		Model:       config.Model,
+		Messages:    anthropicMessages,
+		Temperature: config.Temperature,
+		MaxTokens:   config.MaxTokens,
+		System:      systemMessage,
+		Tools: []Tool{
+			{
+				Name:        objectToolName,
+				Description: "Respond with an object matching the input schema.",
+				InputSchema: inputSchema,
+			},
+		},
+		ToolChoice: &ToolChoice{Type: "tool", Name: objectToolName},
+	}
+
+	return reqBody, wrapped, nil
+}
+
+// generateObjectWithTool forces the model to call a tool whose input schema matches
+// the target and returns the tool input as the result's text
+func (p *Provider) generateObjectWithTool(ctx context.Context, config *ai.Config, target interface{}) (*ai.Result, error) {
+	reqBody, wrapped, err := objectToolRequest(config, target)
+	if err != nil {
+		return nil, err
+	}
+
+	anthropicResp, err := p.send(ctx, config, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, block := range anthropicResp.Content {
+		if block.Type != "tool_use" || block.Name != objectToolName {
+			continue
+		}
+
+		if !wrapped {
+			return objectResult(anthropicResp, string(block.Input)), nil
+		}
+
+		var input map[string]json.RawMessage
+		if err := json.Unmarshal(block.Input, &input); err != nil {
+			return nil, &ai.InvalidObjectError{Raw: string(block.Input), Err: err}
+		}
+
+		value, ok := input[wrappedValueProperty]
+		if !ok {
+			return nil, &ai.InvalidObjectError{Raw: string(block.Input), Err: fmt.Errorf("missing required property %q", wrappedValueProperty)}
+		}
+		return objectResult(anthropicResp, string(value)), nil
+	}
+
+	// Fall back to any text the model returned instead of calling the tool
+	if text := convertContent(anthropicResp.Content).Text; text != "" {
+		return objectResult(anthropicResp, text), nil
+	}
+
+	return nil, ErrInvalidResponse
+}
+
+// objectPromptRequest builds a request that describes the target's schema in the system prompt
+func objectPromptRequest(config *ai.Config, target interface{}) (Request, error) {
+	// Instruct the model to return JSON matching the target's schema
+	systemMsg, err := ai.ObjectInstructions(target)
+	if err != nil {
+		return Request{}, err
+	}
+
+	// Prepare messages
+	anthropicMessages, existingSystemMsg := convertMessages(config.Messages)
+
+	// If there's already a system message, append our JSON instruction
+	if existingSystemMsg != "" {
+		systemMsg = existingSystemMsg + "\n\n" + systemMsg
+	}
+
+	return Request{
+		Model:       config.Model,
+		Messages:    anthropicMessages,
+		Temperature: config.Temperature,
+		MaxTokens:   config.MaxTokens,
+		System:      systemMsg,
+	}, nil
+}
+
+// generateObjectWithPrompt describes the target's schema in the system prompt
+// and returns the model's text response
+func (p *Provider) generateObjectWithPrompt(ctx context.Context, config *ai.Config, target interface{}) (*ai.Result, error) {
+	reqBody, err := objectPromptRequest(config, target)
+	if err != nil {
+		return nil, err
+	}
+
+	anthropicResp, err := p.send(ctx, config, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(anthropicResp.Content) == 0 || anthropicResp.Content[0].Text == "" {
+		return nil, ErrInvalidResponse
+	}
+
+	return objectResult(anthropicResp, anthropicResp.Content[0].Text), nil
+}
+
+// objectResult converts a structured response to an ai.Result holding the object's JSON
+func objectResult(resp *Response, text string) *ai.Result {
+	result := &ai.Result{
+		Text:         text,
+		FinishReason: convertStopReason(resp.StopReason),
+		Usage:        convertUsage(resp.Usage),
+		ID:           resp.ID,
+		Model:        resp.Model,
+	}
+
+	// As in StreamObject, the forced tool call means the object is complete
+	if result.FinishReason == ai.FinishReasonToolCalls {
+		result.FinishReason = ai.FinishReasonStop
+	}
+	return result
+}
+
+// StreamObject streams a structured response from the Anthropic API. The stream's
+// text is the JSON encoding of the target as it is generated.
+func (p *Provider) StreamObject(ctx context.Context, config *ai.Config, target interface{}) (*ai.Stream, error) {
+	if p.apiKey == "" {
+		return nil, ErrEmptyAPIKey
+	}
+
+	if err := checkContent(config.Messages); err != nil {
+		return nil, err
+	}
+
+	var reqBody Request
+	var wrapped, tool bool
+	var err error
+
+	switch config.ObjectMode {
+	case ai.ObjectModeAuto, ai.ObjectModeTool, ai.ObjectModeSchema:
+		reqBody, wrapped, err = objectToolRequest(config, target)
+		tool = true
+	case ai.ObjectModePrompt:
+		reqBody, err = objectPromptRequest(config, target)
+	default:
+		err = fmt.Errorf("%w: %s", ai.ErrObjectModeNotSupported, config.ObjectMode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	reqBody.Stream = true
+
+	resp, err := p.do(ctx, config, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &objectStreamReader{reader: newStreamReader(ctx, resp), tool: tool}
+	if wrapped {
+		reader.unwrapper = partialjson.NewUnwrapper(wrappedValueProperty)
+	}
+	return ai.NewStream(reader), nil
+}
+
+// objectStreamReader turns a streamed structured response into deltas whose text is the object's JSON
+type objectStreamReader struct {
+	reader    ai.StreamReader
+	tool      bool                   // the JSON is streamed as the input of the object tool
+	unwrapper *partialjson.Unwrapper // removes the object wrapping a non-object target, if any
+	closed    bool                   // the underlying reader has finished
+}
+
+// Recv returns the next delta of the object's JSON
+func (r *objectStreamReader) Recv() (ai.Delta, error) {
+	if r.closed {
+		return ai.Delta{}, io.EOF
+	}
+
+	delta, err := r.reader.Recv()
+	if err == io.EOF && r.unwrapper != nil {
+		r.closed = true
+		return ai.Delta{Text: r.unwrapper.Close()}, nil
+	}
+	if err != nil {
+		return delta, err
+	}
+
+	if r.tool {
+		delta.Text = ""
+		if delta.ToolCall != nil {
+			delta.Text = delta.ToolCall.Arguments
+			delta.ToolCall = nil
+		}
+
+		// The forced tool call is how the object is returned, not a request to call a tool
+		if delta.FinishReason == ai.FinishReasonToolCalls {
+			delta.FinishReason = ai.FinishReasonStop
+		}
+	}
+
+	if delta.Text != "" && r.unwrapper != nil {
+		text, err := r.unwrapper.Write(delta.Text)
+		if err != nil {
+			return ai.Delta{}, &ai.InvalidObjectError{Raw: delta.Text, Err: err}
+		}
+		delta.Text = text
+	}
+	return delta, nil
+}
+
+// Close closes the underlying reader
+func (r *objectStreamReader) Close() error {
+	return r.reader.Close()
+}
@@ -0,0 +1,189 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+// objectServer records the request it receives and replies with the given response
+func objectServer(t *testing.T, request *Request, response Response) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Fatalf("failed to encode response: %v", err)
+		}
+	}))
+}
+
+func TestGetObjectWithTool(t *testing.T) {
+	var request Request
+	server := objectServer(t, &request, Response{
+		Content: []Content{
+			{Type: "tool_use", ID: "toolu_1", Name: objectToolName, Input: json.RawMessage(`{"name":"Ada","age":36}`)},
+		},
+		StopReason: "tool_use",
+	})
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var person TestStruct
+	err := provider.GetObject(context.Background(), &ai.Config{
+		Model: "claude-3-haiku-20240307",
+		Messages: []ai.Message{
+			ai.SystemMessage("You extract people."),
+			ai.UserMessage("Ada Lovelace was 36."),
+		},
+	}, &person)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if person.Name != "Ada" || person.Age != 36 {
+		t.Errorf("Unexpected person: %+v", person)
+	}
+
+	if request.ToolChoice == nil || request.ToolChoice.Type != "tool" || request.ToolChoice.Name != objectToolName {
+		t.Errorf("Expected tool choice forcing %s, got %+v", objectToolName, request.ToolChoice)
+	}
+	if len(request.Tools) != 1 {
+		t.Fatalf("Expected 1 tool, got %d", len(request.Tools))
+	}
+
+	var schema ai.Schema
+	if err := json.Unmarshal(request.Tools[0].InputSchema, &schema); err != nil {
+		t.Fatalf("failed to decode input schema: %v", err)
+	}
+	if schema.Type != "object" || !reflect.DeepEqual(schema.Required, []string{"name", "age"}) {
+		t.Errorf("Unexpected input schema: %s", request.Tools[0].InputSchema)
+	}
+
+	// The system prompt is passed through unchanged
+	if request.System != "You extract people." {
+		t.Errorf("Unexpected system prompt: %s", request.System)
+	}
+}
+
+func TestGetObjectWithToolWrapsNonObjects(t *testing.T) {
+	var request Request
+	server := objectServer(t, &request, Response{
+		Content: []Content{
+			{Type: "tool_use", ID: "toolu_1", Name: objectToolName, Input: json.RawMessage(`{"value":["red","green"]}`)},
+		},
+	})
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var colors []string
+	err := provider.GetObject(context.Background(), &ai.Config{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []ai.Message{ai.UserMessage("List two colors.")},
+	}, &colors)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(colors, []string{"red", "green"}) {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+}
+
+func TestGetObjectPromptMode(t *testing.T) {
+	var request Request
+	server := objectServer(t, &request, Response{
+		Content: []Content{{Type: "text", Text: `{"name":"Ada","age":36}`}},
+	})
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var person TestStruct
+	err := provider.GetObject(context.Background(), &ai.Config{
+		Model:      "claude-3-haiku-20240307",
+		Messages:   []ai.Message{ai.UserMessage("Ada Lovelace was 36.")},
+		ObjectMode: ai.ObjectModePrompt,
+	}, &person)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(request.Tools) != 0 || request.ToolChoice != nil {
+		t.Errorf("Expected no tools in prompt mode, got %+v", request.Tools)
+	}
+	if person.Name != "Ada" {
+		t.Errorf("Unexpected person: %+v", person)
+	}
+}
+
+func TestGetObjectUnsupportedMode(t *testing.T) {
+	provider := New(WithAPIKey("test-key"))
+
+	var person TestStruct
+	err := provider.GetObject(context.Background(), &ai.Config{
+		Model:      "claude-3-haiku-20240307",
+		ObjectMode: ai.ObjectModeJSON,
+	}, &person)
+	if !errors.Is(err, ai.ErrObjectModeNotSupported) {
+		t.Errorf("Expected ErrObjectModeNotSupported, got %v", err)
+	}
+}
+
+func TestGenerateObject(t *testing.T) {
+	var request Request
+	server := objectServer(t, &request, Response{
+		ID:    "msg_1",
+		Model: "claude-3-haiku-20240307",
+		Content: []Content{
+			{Type: "tool_use", ID: "toolu_1", Name: objectToolName, Input: json.RawMessage(`{"name":"Ada","age":36}`)},
+		},
+		StopReason: "tool_use",
+		Usage:      &Usage{InputTokens: 30, OutputTokens: 12},
+	})
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var person TestStruct
+	result, err := provider.GenerateObject(context.Background(), &ai.Config{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []ai.Message{ai.UserMessage("Ada Lovelace was 36.")},
+	}, &person)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if person.Name != "Ada" || person.Age != 36 {
+		t.Errorf("Unexpected person: %+v", person)
+	}
+	if result.Text != `{"name":"Ada","age":36}` {
+		t.Errorf("Expected tool input as result text, got %s", result.Text)
+	}
+	if result.ID != "msg_1" || result.Model != "claude-3-haiku-20240307" || result.FinishReason != ai.FinishReasonStop {
+		t.Errorf("Unexpected metadata: %+v", result)
+	}
+	if result.Usage.InputTokens != 30 || result.Usage.OutputTokens != 12 {
+		t.Errorf("Unexpected usage: %+v", result.Usage)
+	}
+}
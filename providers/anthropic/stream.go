@@ -0,0 +1,147 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gnfisher/go-ai-sdk"
+	"github.com/gnfisher/go-ai-sdk/internal/sse"
+)
+
+// StreamEvent represents an event in a streamed Anthropic response
+type StreamEvent struct {
+	Type         string      `json:"type"`
+	Index        int         `json:"index"`
+	Message      *Response   `json:"message,omitempty"`
+	ContentBlock *Content    `json:"content_block,omitempty"`
+	Delta        *EventDelta `json:"delta,omitempty"`
+	Usage        *Usage      `json:"usage,omitempty"`
+	Error        *Error      `json:"error,omitempty"`
+}
+
+// EventDelta holds the incremental content of a content_block_delta or message_delta event
+type EventDelta struct {
+	Type        string `json:"type,omitempty"`
+	Text        string `json:"text,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"`
+	StopReason  string `json:"stop_reason,omitempty"`
+}
+
+// streamReader reads deltas from an Anthropic response stream
+type streamReader struct {
+	ctx    context.Context
+	body   io.ReadCloser
+	events *sse.Reader
+}
+
+// StreamText streams a response from the Anthropic API
+func (p *Provider) StreamText(ctx context.Context, config *ai.Config) (*ai.Stream, error) {
+	if p.apiKey == "" {
+		return nil, ErrEmptyAPIKey
+	}
+
+	if err := checkContent(config.Messages); err != nil {
+		return nil, err
+	}
+
+	reqBody := newRequest(config)
+	reqBody.Stream = true
+
+	resp, err := p.do(ctx, config, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	return ai.NewStream(newStreamReader(ctx, resp)), nil
+}
+
+// newStreamReader creates a streamReader for a successful streaming response
+func newStreamReader(ctx context.Context, resp *http.Response) *streamReader {
+	return &streamReader{
+		ctx:    ctx,
+		body:   resp.Body,
+		events: sse.NewReader(resp.Body),
+	}
+}
+
+// Recv returns the next delta from the stream
+func (r *streamReader) Recv() (ai.Delta, error) {
+	for {
+		event, err := r.events.Next()
+		if err != nil {
+			if ctxErr := r.ctx.Err(); ctxErr != nil {
+				return ai.Delta{}, ctxErr
+			}
+			if err == io.EOF {
+				return ai.Delta{}, io.ErrUnexpectedEOF
+			}
+			return ai.Delta{}, fmt.Errorf("failed to read stream: %w", err)
+		}
+
+		var streamEvent StreamEvent
+		if err := json.Unmarshal([]byte(event.Data), &streamEvent); err != nil {
+			return ai.Delta{}, fmt.Errorf("failed to unmarshal stream event: %w", err)
+		}
+
+		switch streamEvent.Type {
+		case "message_start":
+			if message := streamEvent.Message; message != nil {
+				usage := convertUsage(message.Usage)
+				return ai.Delta{ID: message.ID, Model: message.Model, Usage: &usage}, nil
+			}
+
+		case "message_delta":
+			delta := ai.Delta{}
+			if streamEvent.Delta != nil {
+				delta.FinishReason = convertStopReason(streamEvent.Delta.StopReason)
+			}
+			if streamEvent.Usage != nil {
+				usage := convertUsage(streamEvent.Usage)
+				delta.Usage = &usage
+			}
+			return delta, nil
+
+		case "content_block_start":
+			if block := streamEvent.ContentBlock; block != nil && block.Type == "tool_use" {
+				return ai.Delta{
+					ToolCall: &ai.ToolCallDelta{
+						Index: streamEvent.Index,
+						ID:    block.ID,
+						Name:  block.Name,
+					},
+				}, nil
+			}
+
+		case "content_block_delta":
+			if streamEvent.Delta == nil {
+				continue
+			}
+
+			switch streamEvent.Delta.Type {
+			case "text_delta":
+				return ai.Delta{Text: streamEvent.Delta.Text}, nil
+			case "input_json_delta":
+				return ai.Delta{
+					ToolCall: &ai.ToolCallDelta{
+						Index:     streamEvent.Index,
+						Arguments: streamEvent.Delta.PartialJSON,
+					},
+				}, nil
+			}
+
+		case "message_stop":
+			return ai.Delta{}, io.EOF
+
+		case "error":
+			return ai.Delta{}, streamError(streamEvent.Error, []byte(event.Data))
+		}
+	}
+}
+
+// Close closes the response body
+func (r *streamReader) Close() error {
+	return r.body.Close()
+}
@@ -0,0 +1,296 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+// streamServer creates a test server that streams the given events
+func streamServer(t *testing.T, events ...[2]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if !req.Stream {
+			t.Errorf("Expected stream to be true")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event[0], event[1])
+		}
+	}))
+}
+
+func TestStreamText(t *testing.T) {
+	server := streamServer(t,
+		[2]string{"message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude-3-haiku-20240307","usage":{"input_tokens":12,"output_tokens":1}}}`},
+		[2]string{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+		[2]string{"ping", `{"type":"ping"}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":", world!"}}`},
+		[2]string{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+		[2]string{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":6}}`},
+		[2]string{"message_stop", `{"type":"message_stop"}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	stream, err := provider.StreamText(context.Background(), &ai.Config{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []ai.Message{ai.UserMessage("Hello")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	var deltas []string
+	for stream.Next() {
+		deltas = append(deltas, stream.Delta().Text)
+	}
+
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Expected no stream error, got %v", err)
+	}
+
+	if len(deltas) != 2 || deltas[0] != "Hello" || deltas[1] != ", world!" {
+		t.Errorf("Unexpected deltas: %q", deltas)
+	}
+
+	result := stream.Result()
+	if result.Text != "Hello, world!" {
+		t.Errorf("Expected 'Hello, world!', got %s", result.Text)
+	}
+	if result.ID != "msg_1" || result.Model != "claude-3-haiku-20240307" {
+		t.Errorf("Unexpected response metadata: id=%s model=%s", result.ID, result.Model)
+	}
+	if result.FinishReason != ai.FinishReasonStop {
+		t.Errorf("Expected finish reason stop, got %s", result.FinishReason)
+	}
+	if result.Usage.InputTokens != 12 || result.Usage.OutputTokens != 6 {
+		t.Errorf("Unexpected usage: %+v", result.Usage)
+	}
+}
+
+func TestStreamTextToolUse(t *testing.T) {
+	server := streamServer(t,
+		[2]string{"message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}`},
+		[2]string{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me check."}}`},
+		[2]string{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+		[2]string{"content_block_start", `{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`},
+		[2]string{"content_block_stop", `{"type":"content_block_stop","index":1}`},
+		[2]string{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"tool_use"}}`},
+		[2]string{"message_stop", `{"type":"message_stop"}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	stream, err := provider.StreamText(context.Background(), &ai.Config{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []ai.Message{ai.UserMessage("What's the weather in Paris?")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+	}
+
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Expected no stream error, got %v", err)
+	}
+
+	result := stream.Result()
+	if result.FinishReason != ai.FinishReasonToolCalls {
+		t.Errorf("Expected finish reason tool_calls, got %s", result.FinishReason)
+	}
+	if result.Text != "Let me check." {
+		t.Errorf("Expected text 'Let me check.', got %q", result.Text)
+	}
+
+	if len(result.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(result.ToolCalls))
+	}
+
+	call := result.ToolCalls[0]
+	if call.ID != "toolu_1" || call.Name != "get_weather" || string(call.Arguments) != `{"city":"Paris"}` {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+}
+
+func TestStreamTextErrorEvent(t *testing.T) {
+	server := streamServer(t,
+		[2]string{"message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}`},
+		[2]string{"error", `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	stream, err := provider.StreamText(context.Background(), &ai.Config{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []ai.Message{ai.UserMessage("Hello")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+	}
+
+	var apiErr *ai.APIError
+	if !errors.As(stream.Err(), &apiErr) || !errors.Is(stream.Err(), ai.ErrOverloaded) || !apiErr.Retryable {
+		t.Errorf("Expected a retryable overloaded error, got %v", stream.Err())
+	}
+}
+
+func TestStreamTextCancel(t *testing.T) {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello\"}}\n\n")
+		w.(http.Flusher).Flush()
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := provider.StreamText(ctx, &ai.Config{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []ai.Message{ai.UserMessage("Hello")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	if !stream.Next() || stream.Delta().Text != "Hello" {
+		t.Fatalf("Expected first delta 'Hello'")
+	}
+
+	<-started
+	cancel()
+
+	if stream.Next() {
+		t.Errorf("Expected stream to stop after cancellation")
+	}
+	if !errors.Is(stream.Err(), context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", stream.Err())
+	}
+}
+
+func TestStreamObjectWithTool(t *testing.T) {
+	server := streamServer(t,
+		[2]string{"message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude-3-haiku-20240307","usage":{"input_tokens":40,"output_tokens":1}}}`},
+		[2]string{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"respond_with_object","input":{}}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"name\":\"Ad"}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"a\",\"age\":36}"}}`},
+		[2]string{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+		[2]string{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":20}}`},
+		[2]string{"message_stop", `{"type":"message_stop"}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var person TestStruct
+	stream, err := provider.StreamObject(context.Background(), &ai.Config{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []ai.Message{ai.UserMessage("Ada Lovelace was 36.")},
+	}, &person)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	var deltas []string
+	for stream.Next() {
+		deltas = append(deltas, stream.Delta().Text)
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(deltas) != 2 || deltas[0] != `{"name":"Ad` {
+		t.Errorf("Expected tool input as text deltas, got %q", deltas)
+	}
+
+	result := stream.Result()
+	if result.Text != `{"name":"Ada","age":36}` || len(result.ToolCalls) != 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if result.FinishReason != ai.FinishReasonStop || result.Usage.InputTokens != 40 {
+		t.Errorf("Unexpected metadata: %+v", result)
+	}
+}
+
+func TestStreamObjectWithToolUnwrapsNonObjects(t *testing.T) {
+	server := streamServer(t,
+		[2]string{"message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude-3-haiku-20240307","usage":{"input_tokens":40,"output_tokens":1}}}`},
+		[2]string{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"respond_with_object","input":{}}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"value\": [1, "}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"2]}"}}`},
+		[2]string{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":20}}`},
+		[2]string{"message_stop", `{"type":"message_stop"}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var numbers []int
+	stream, err := provider.StreamObject(context.Background(), &ai.Config{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []ai.Message{ai.UserMessage("Count to two.")},
+	}, &numbers)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if text := stream.Result().Text; text != ` [1, 2]` {
+		t.Errorf("Expected unwrapped JSON, got %q", text)
+	}
+}
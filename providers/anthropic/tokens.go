@@ -0,0 +1,53 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+// countTokensPath is the token counting endpoint, relative to the Messages API URL
+const countTokensPath = "/count_tokens"
+
+// CountTokensResponse represents a response from the Anthropic token counting API
+type CountTokensResponse struct {
+	InputTokens int `json:"input_tokens"`
+}
+
+// CountTokens counts the input tokens of a request with the Anthropic token counting API,
+// including images, documents and tools
+func (p *Provider) CountTokens(ctx context.Context, config *ai.Config) (int, error) {
+	if p.apiKey == "" {
+		return 0, ErrEmptyAPIKey
+	}
+
+	if err := checkContent(config.Messages); err != nil {
+		return 0, err
+	}
+
+	// The endpoint takes the request without generation settings
+	reqBody := newRequest(config)
+	reqBody.MaxTokens = 0
+	reqBody.Temperature = 0
+
+	resp, err := p.post(ctx, config, p.apiURL+countTokensPath, reqBody)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var countResp CountTokensResponse
+	if err := json.Unmarshal(body, &countResp); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return countResp.InputTokens, nil
+}
@@ -0,0 +1,69 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+func TestCountTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages/count_tokens" {
+			t.Errorf("Expected the count_tokens endpoint, got %s", r.URL.Path)
+		}
+
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if req["model"] != "claude-3-haiku" || req["system"] != "Be brief." {
+			t.Errorf("Unexpected request: %v", req)
+		}
+		if _, ok := req["max_tokens"]; ok {
+			t.Errorf("Expected no max_tokens, got %v", req["max_tokens"])
+		}
+
+		w.Write([]byte(`{"input_tokens": 14}`))
+	}))
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL+"/v1/messages"),
+	)
+
+	count, err := provider.CountTokens(context.Background(), &ai.Config{
+		Model:     "claude-3-haiku",
+		MaxTokens: 1000,
+		Messages:  []ai.Message{ai.SystemMessage("Be brief."), ai.UserMessage("Hello, Claude")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if count != 14 {
+		t.Errorf("Expected 14 tokens, got %d", count)
+	}
+}
+
+func TestCountTokensError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"type": "error", "error": {"type": "not_found_error", "message": "model: claude-unknown"}}`))
+	}))
+	defer server.Close()
+
+	provider := New(WithAPIKey("test-key"), WithAPIURL(server.URL))
+
+	_, err := provider.CountTokens(context.Background(), &ai.Config{
+		Model:    "claude-unknown",
+		Messages: []ai.Message{ai.UserMessage("Hello")},
+	})
+	if !errors.Is(err, ai.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gnfisher/go-ai-sdk"
+	"github.com/gnfisher/go-ai-sdk/internal/retry"
+)
+
+// newAPIError builds the error for a response with a non-200 status code
+func newAPIError(resp *http.Response, body []byte) *ai.APIError {
+	apiErr := &ai.APIError{
+		Provider:   ai.ProviderOpenAI,
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("x-request-id"),
+		Retryable:  retry.ShouldRetry(resp),
+		Body:       body,
+	}
+
+	var errResp Response
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+		apiErr.Type = errResp.Error.Type
+		apiErr.Code = errResp.Error.Code
+		apiErr.Message = errResp.Error.Message
+	}
+
+	apiErr.Category = errorCategory(apiErr)
+	if apiErr.Category == ai.ErrQuotaExceeded {
+		// Retrying does not help until the account's billing changes
+		apiErr.Retryable = false
+	}
+	return apiErr
+}
+
+// shouldRetry reports whether a request should be retried after the given response,
+// classifying the error in its body so that a 429 for an exhausted quota is not retried
+// like a rate limit. The body is restored for the caller to read.
+func shouldRetry(resp *http.Response) bool {
+	if !retry.ShouldRetry(resp) {
+		return false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	if err != nil {
+		return true
+	}
+
+	return newAPIError(resp, body).Retryable
+}
+
+// streamError builds the error for an error reported in the middle of a stream
+func streamError(detail *Error, body []byte) *ai.APIError {
+	apiErr := &ai.APIError{
+		Provider: ai.ProviderOpenAI,
+		Type:     detail.Type,
+		Code:     detail.Code,
+		Message:  detail.Message,
+		Body:     body,
+	}
+	apiErr.Category = errorCategory(apiErr)
+	apiErr.Retryable = apiErr.Category == ai.ErrRateLimited || apiErr.Category == ai.ErrOverloaded || apiErr.Category == ai.ErrServerError
+	return apiErr
+}
+
+// errorCategory classifies an error by the code and type reported by the API,
+// falling back to its status code
+func errorCategory(apiErr *ai.APIError) error {
+	switch apiErr.Code {
+	case "context_length_exceeded", "string_above_max_length":
+		return ai.ErrContextLengthExceeded
+	case "content_policy_violation", "content_filter":
+		return ai.ErrContentPolicy
+	case "insufficient_quota", "billing_hard_limit_reached":
+		return ai.ErrQuotaExceeded
+	case "rate_limit_exceeded":
+		return ai.ErrRateLimited
+	case "invalid_api_key":
+		return ai.ErrAuthentication
+	case "model_not_found":
+		return ai.ErrNotFound
+	}
+
+	switch apiErr.Type {
+	case "insufficient_quota":
+		return ai.ErrQuotaExceeded
+	case "server_error":
+		return ai.ErrServerError
+	case "invalid_request_error":
+		if strings.Contains(apiErr.Message, "maximum context length") {
+			return ai.ErrContextLengthExceeded
+		}
+	}
+
+	if category := ai.StatusCategory(apiErr.StatusCode); category != nil {
+		return category
+	}
+	if apiErr.Type == "invalid_request_error" {
+		return ai.ErrInvalidRequest
+	}
+	return ai.ErrServerError
+}
@@ -0,0 +1,88 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		category  error
+		retryable bool
+	}{
+		{"invalid key", 401, `{"error":{"message":"Incorrect API key","type":"invalid_request_error","code":"invalid_api_key"}}`, ai.ErrAuthentication, false},
+		{"rate limit", 429, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`, ai.ErrRateLimited, true},
+		{"quota", 429, `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`, ai.ErrQuotaExceeded, false},
+		{"context length", 400, `{"error":{"message":"This model's maximum context length is 128000 tokens","type":"invalid_request_error","code":"context_length_exceeded"}}`, ai.ErrContextLengthExceeded, false},
+		{"content policy", 400, `{"error":{"message":"Your request was rejected","type":"invalid_request_error","code":"content_policy_violation"}}`, ai.ErrContentPolicy, false},
+		{"bad request", 400, `{"error":{"message":"Invalid value","type":"invalid_request_error","param":"temperature"}}`, ai.ErrInvalidRequest, false},
+		{"server error", 500, `not json`, ai.ErrServerError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("x-request-id", "req_123")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			provider := New(WithAPIKey("test-key"), WithAPIURL(server.URL))
+
+			_, err := provider.GetText(context.Background(), &ai.Config{
+				Model:    "gpt-4o",
+				Messages: []ai.Message{ai.UserMessage("Hello")},
+			})
+
+			var apiErr *ai.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Expected *ai.APIError, got %v", err)
+			}
+			if !errors.Is(err, tt.category) {
+				t.Errorf("Expected %v, got %v", tt.category, apiErr.Category)
+			}
+			if apiErr.Provider != ai.ProviderOpenAI || apiErr.StatusCode != tt.status || apiErr.RequestID != "req_123" {
+				t.Errorf("Unexpected error fields: %+v", apiErr)
+			}
+			if apiErr.Retryable != tt.retryable {
+				t.Errorf("Expected retryable %v, got %v", tt.retryable, apiErr.Retryable)
+			}
+			if string(apiErr.Body) != tt.body {
+				t.Errorf("Expected raw body %s, got %s", tt.body, apiErr.Body)
+			}
+		})
+	}
+}
+
+func TestStreamChunkError(t *testing.T) {
+	server := streamServer(t, `{"error":{"message":"The server had an error","type":"server_error"}}`)
+	defer server.Close()
+
+	provider := New(WithAPIKey("test-key"), WithAPIURL(server.URL))
+
+	stream, err := provider.StreamText(context.Background(), &ai.Config{
+		Model:    "gpt-4o",
+		Messages: []ai.Message{ai.UserMessage("Hello")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+	}
+
+	var apiErr *ai.APIError
+	if !errors.As(stream.Err(), &apiErr) || !errors.Is(stream.Err(), ai.ErrServerError) || !apiErr.Retryable {
+		t.Errorf("Expected a retryable server error, got %v", stream.Err())
+	}
+}
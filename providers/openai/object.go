@@ -0,0 +1,376 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gnfisher/go-ai-sdk"
+	"github.com/gnfisher/go-ai-sdk/internal/partialjson"
+)
+
+// wrappedValueProperty is the property holding non-object targets in json_schema mode,
+// which requires the root of the schema to be an object
+const wrappedValueProperty = "value"
+
+// isResponseFormatError reports whether err is the API rejecting the response_format parameter
+func isResponseFormatError(err error) bool {
+	var apiErr *ai.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+
+	var errResp Response
+	if err := json.Unmarshal(apiErr.Body, &errResp); err != nil || errResp.Error == nil {
+		return false
+	}
+	return errResp.Error.Param == "response_format" || strings.Contains(errResp.Error.Message, "response_format")
+}
+
+// jsonSchemaModels are the model prefixes that support json_schema structured outputs
+var jsonSchemaModels = []string{"gpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "chatgpt-4o", "o1", "o3", "o4"}
+
+// jsonSchemaExceptions are models matching jsonSchemaModels that predate structured outputs
+var jsonSchemaExceptions = []string{"gpt-4o-2024-05-13", "o1-preview", "o1-mini"}
+
+// jsonObjectModels are the model prefixes that support json_object mode but not json_schema
+var jsonObjectModels = []string{"gpt-3.5-turbo", "gpt-4-turbo", "gpt-4-1106", "gpt-4-0125"}
+
+// defaultObjectMode returns the most reliable object mode the model supports
+func defaultObjectMode(model string) ai.ObjectMode {
+	for _, exception := range jsonSchemaExceptions {
+		if strings.HasPrefix(model, exception) {
+			return ai.ObjectModeJSON
+		}
+	}
+	for _, prefix := range jsonSchemaModels {
+		if strings.HasPrefix(model, prefix) {
+			return ai.ObjectModeSchema
+		}
+	}
+	for _, prefix := range jsonObjectModels {
+		if strings.HasPrefix(model, prefix) {
+			return ai.ObjectModeJSON
+		}
+	}
+	return ai.ObjectModePrompt
+}
+
+// objectRequest builds a request for a structured response for target using the given mode.
+// It reports whether a non-object target was wrapped in an object.
+func objectRequest(config *ai.Config, target interface{}, mode ai.ObjectMode) (Request, bool, error) {
+	messages := config.Messages
+	var format *ResponseFormat
+	var wrapped bool
+
+	switch mode {
+	case ai.ObjectModeSchema:
+		schema, err := ai.SchemaFor(target)
+		if err != nil {
+			return Request{}, false, fmt.Errorf("failed to generate schema: %w", err)
+		}
+
+		if schema.Type != "object" || schema.Properties == nil {
+			schema = &ai.Schema{
+				Type:       "object",
+				Properties: map[string]*ai.Schema{wrappedValueProperty: schema},
+				Required:   []string{wrappedValueProperty},
+			}
+			wrapped = true
+		}
+
+		strict, ok := strictSchema(schema)
+		format = &ResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &JSONSchema{
+				Name:   schemaName(target),
+				Schema: schema,
+			},
+		}
+		if ok {
+			format.JSONSchema.Schema = strict
+			format.JSONSchema.Strict = true
+		}
+
+	case ai.ObjectModeJSON, ai.ObjectModePrompt:
+		// JSON mode requires the word "JSON" in the messages, which the instructions provide
+		instructions, err := ai.ObjectInstructions(target)
+		if err != nil {
+			return Request{}, false, err
+		}
+		messages = withInstructions(config.Messages, instructions)
+
+		if mode == ai.ObjectModeJSON {
+			format = &ResponseFormat{Type: "json_object"}
+		}
+
+	default:
+		return Request{}, false, fmt.Errorf("%w: %s", ai.ErrObjectModeNotSupported, mode)
+	}
+
+	reqBody := newRequest(&ai.Config{
+		Model:       config.Model,
+		Messages:    messages,
+		MaxTokens:   config.MaxTokens,
+		Temperature: config.Temperature,
+	})
+	reqBody.ResponseFormat = format
+
+	return reqBody, wrapped, nil
+}
+
+// generateObject requests a structured response for target using the given mode
+func (p *Provider) generateObject(ctx context.Context, config *ai.Config, target interface{}, mode ai.ObjectMode) (*ai.Result, error) {
+	reqBody, wrapped, err := objectRequest(config, target, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := p.generate(ctx, config, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Text == "" {
+		return nil, ErrInvalidResponse
+	}
+
+	if wrapped {
+		text, err := unwrapValue(result.Text)
+		if err != nil {
+			return nil, err
+		}
+		result.Text = text
+	}
+
+	return result, nil
+}
+
+// streamObject starts streaming a structured response for target using the given mode
+func (p *Provider) streamObject(ctx context.Context, config *ai.Config, target interface{}, mode ai.ObjectMode) (ai.StreamReader, error) {
+	reqBody, wrapped, err := objectRequest(config, target, mode)
+	if err != nil {
+		return nil, err
+	}
+	reqBody.Stream = true
+	reqBody.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	resp, err := p.do(ctx, config, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader ai.StreamReader = newStreamReader(ctx, resp)
+	if wrapped {
+		reader = &unwrapReader{reader: reader, unwrapper: partialjson.NewUnwrapper(wrappedValueProperty)}
+	}
+	return reader, nil
+}
+
+// StreamObject streams a structured response from the OpenAI API. The stream's
+// text is the JSON encoding of the target as it is generated.
+func (p *Provider) StreamObject(ctx context.Context, config *ai.Config, target interface{}) (*ai.Stream, error) {
+	if p.apiKey == "" {
+		return nil, ErrEmptyAPIKey
+	}
+
+	if err := checkContent(config.Messages); err != nil {
+		return nil, err
+	}
+
+	mode := config.ObjectMode
+	if mode == ai.ObjectModeAuto {
+		mode = defaultObjectMode(config.Model)
+	}
+
+	reader, err := p.streamObject(ctx, config, target, mode)
+
+	// Fall back to the prompt-based mode if the model rejects the response format
+	if err != nil && config.ObjectMode == ai.ObjectModeAuto && mode != ai.ObjectModePrompt && isResponseFormatError(err) {
+		reader, err = p.streamObject(ctx, config, target, ai.ObjectModePrompt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ai.NewStream(reader), nil
+}
+
+// unwrapReader removes the object wrapping a non-object target from a streamed response
+type unwrapReader struct {
+	reader    ai.StreamReader
+	unwrapper *partialjson.Unwrapper
+	closed    bool // the underlying reader has finished
+}
+
+// Recv returns the next delta with the wrapper removed from its text
+func (r *unwrapReader) Recv() (ai.Delta, error) {
+	if r.closed {
+		return ai.Delta{}, io.EOF
+	}
+
+	delta, err := r.reader.Recv()
+	if err == io.EOF {
+		r.closed = true
+		return ai.Delta{Text: r.unwrapper.Close()}, nil
+	}
+	if err != nil {
+		return delta, err
+	}
+
+	if delta.Text != "" {
+		text, err := r.unwrapper.Write(delta.Text)
+		if err != nil {
+			return ai.Delta{}, &ai.InvalidObjectError{Raw: delta.Text, Err: err}
+		}
+		delta.Text = text
+	}
+	return delta, nil
+}
+
+// Close closes the underlying reader
+func (r *unwrapReader) Close() error {
+	return r.reader.Close()
+}
+
+// withInstructions appends the instructions to the first system message, or adds a new one.
+// A message made of parts gets the instructions as another text part, since its Content is not sent.
+func withInstructions(messages []ai.Message, instructions string) []ai.Message {
+	result := make([]ai.Message, 0, len(messages)+1)
+	hasSystemMsg := false
+	for _, msg := range messages {
+		if msg.Role == ai.RoleSystem && !hasSystemMsg {
+			hasSystemMsg = true
+			if len(msg.Parts) > 0 {
+				msg.Parts = append(msg.Parts[:len(msg.Parts):len(msg.Parts)], ai.TextPart(instructions))
+			} else {
+				msg.Content = msg.Content + "\n\n" + instructions
+			}
+		}
+		result = append(result, msg)
+	}
+
+	if !hasSystemMsg {
+		result = append([]ai.Message{ai.SystemMessage(instructions)}, result...)
+	}
+
+	return result
+}
+
+// invalidNameChars matches characters not allowed in json_schema names
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// schemaName derives a json_schema name from the target's type
+func schemaName(target interface{}) string {
+	t := reflect.TypeOf(target)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	name := ""
+	if t != nil {
+		name = invalidNameChars.ReplaceAllString(t.Name(), "_")
+	}
+	if name == "" {
+		return "response"
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// strictFormats are the string formats supported by strict structured outputs
+var strictFormats = map[string]bool{
+	"date-time": true, "time": true, "date": true, "duration": true,
+	"email": true, "hostname": true, "ipv4": true, "ipv6": true, "uuid": true,
+}
+
+// strictSchema converts a schema to the subset accepted by strict structured outputs:
+// every object must list all properties as required and forbid additional properties,
+// so optional properties become nullable instead. It reports false if the schema uses
+// features strict mode cannot express, such as maps or values of any type.
+func strictSchema(schema *ai.Schema) (*ai.Schema, bool) {
+	if schema == nil {
+		return nil, true
+	}
+
+	strict := *schema
+	if !strictFormats[strict.Format] {
+		strict.Format = ""
+	}
+
+	switch strict.Type {
+	case "":
+		if len(strict.AnyOf) == 0 {
+			return nil, false
+		}
+	case "object":
+		if strict.AdditionalProperties != nil {
+			return nil, false
+		}
+
+		required := make(map[string]bool, len(schema.Required))
+		for _, name := range schema.Required {
+			required[name] = true
+		}
+
+		strict.Properties = make(map[string]*ai.Schema, len(schema.Properties))
+		strict.Required = make([]string, 0, len(schema.Properties))
+		for name, property := range schema.Properties {
+			strictProperty, ok := strictSchema(property)
+			if !ok {
+				return nil, false
+			}
+			if !required[name] {
+				strictProperty = &ai.Schema{AnyOf: []*ai.Schema{strictProperty, {Type: "null"}}}
+			}
+			strict.Properties[name] = strictProperty
+			strict.Required = append(strict.Required, name)
+		}
+		sort.Strings(strict.Required)
+		strict.AdditionalProperties = false
+
+	case "array":
+		items, ok := strictSchema(schema.Items)
+		if !ok || items == nil {
+			return nil, false
+		}
+		strict.Items = items
+	}
+
+	if len(schema.AnyOf) > 0 {
+		strict.AnyOf = make([]*ai.Schema, len(schema.AnyOf))
+		for i, option := range schema.AnyOf {
+			strictOption, ok := strictSchema(option)
+			if !ok {
+				return nil, false
+			}
+			strict.AnyOf[i] = strictOption
+		}
+	}
+
+	return &strict, true
+}
+
+// unwrapValue extracts the wrapped value from a json_schema response for a non-object target
+func unwrapValue(text string) (string, error) {
+	var wrapper map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(text), &wrapper); err != nil {
+		return "", &ai.InvalidObjectError{Raw: text, Err: err}
+	}
+
+	value, ok := wrapper[wrappedValueProperty]
+	if !ok {
+		return "", &ai.InvalidObjectError{Raw: text, Err: fmt.Errorf("missing required property %q", wrappedValueProperty)}
+	}
+
+	return string(value), nil
+}
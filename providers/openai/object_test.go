@@ -0,0 +1,329 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+type testPerson struct {
+	Name     string   `json:"name"`
+	Nickname string   `json:"nickname,omitempty"`
+	Hobbies  []string `json:"hobbies"`
+}
+
+func TestDefaultObjectMode(t *testing.T) {
+	tests := map[string]ai.ObjectMode{
+		"gpt-4o":            ai.ObjectModeSchema,
+		"gpt-4o-mini":       ai.ObjectModeSchema,
+		"gpt-4o-2024-05-13": ai.ObjectModeJSON,
+		"gpt-4.1":           ai.ObjectModeSchema,
+		"o3-mini":           ai.ObjectModeSchema,
+		"o1-mini":           ai.ObjectModeJSON,
+		"gpt-4-turbo":       ai.ObjectModeJSON,
+		"gpt-3.5-turbo":     ai.ObjectModeJSON,
+		"gpt-4":             ai.ObjectModePrompt,
+		"my-finetune":       ai.ObjectModePrompt,
+	}
+
+	for model, expected := range tests {
+		if got := defaultObjectMode(model); got != expected {
+			t.Errorf("defaultObjectMode(%q) = %s, want %s", model, got, expected)
+		}
+	}
+}
+
+func TestStrictSchema(t *testing.T) {
+	schema, err := ai.SchemaFor(&testPerson{})
+	if err != nil {
+		t.Fatalf("failed to generate schema: %v", err)
+	}
+
+	strict, ok := strictSchema(schema)
+	if !ok {
+		t.Fatalf("Expected schema to be convertible to strict mode")
+	}
+
+	if strict.AdditionalProperties != false {
+		t.Errorf("Expected additionalProperties false, got %v", strict.AdditionalProperties)
+	}
+	if !reflect.DeepEqual(strict.Required, []string{"hobbies", "name", "nickname"}) {
+		t.Errorf("Expected all properties to be required, got %v", strict.Required)
+	}
+
+	nickname := strict.Properties["nickname"]
+	if len(nickname.AnyOf) != 2 || nickname.AnyOf[0].Type != "string" || nickname.AnyOf[1].Type != "null" {
+		t.Errorf("Expected optional property to be nullable, got %+v", nickname)
+	}
+
+	// The original schema is not modified
+	if !reflect.DeepEqual(schema.Required, []string{"name", "hobbies"}) {
+		t.Errorf("Expected original schema to be unchanged, got %v", schema.Required)
+	}
+
+	// Maps cannot be expressed in strict mode
+	mapSchema, _ := ai.SchemaFor(map[string]int{})
+	if _, ok := strictSchema(mapSchema); ok {
+		t.Errorf("Expected map schema to be rejected")
+	}
+}
+
+// objectServer records the requests it receives and replies with the given responses in order
+func objectServer(t *testing.T, requests *[]Request, responses ...[2]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		*requests = append(*requests, req)
+
+		response := responses[len(*requests)-1]
+		w.WriteHeader(response[0].(int))
+		fmt.Fprint(w, response[1].(string))
+	}))
+}
+
+func TestGetObjectJSONSchema(t *testing.T) {
+	var requests []Request
+	server := objectServer(t, &requests,
+		[2]interface{}{http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"{\"name\":\"Ada\",\"nickname\":null,\"hobbies\":[\"math\"]}"}}]}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var person testPerson
+	err := provider.GetObject(context.Background(), &ai.Config{
+		Model:    "gpt-4o",
+		Messages: []ai.Message{ai.UserMessage("Ada Lovelace liked math.")},
+	}, &person)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if person.Name != "Ada" || len(person.Hobbies) != 1 {
+		t.Errorf("Unexpected person: %+v", person)
+	}
+
+	format := requests[0].ResponseFormat
+	if format == nil || format.Type != "json_schema" || format.JSONSchema == nil {
+		t.Fatalf("Expected json_schema response format, got %+v", format)
+	}
+	if format.JSONSchema.Name != "testPerson" || !format.JSONSchema.Strict {
+		t.Errorf("Expected strict schema named testPerson, got %+v", format.JSONSchema)
+	}
+
+	// The schema is enforced by the API, so no instructions are added
+	if len(requests[0].Messages) != 1 {
+		t.Errorf("Expected only the user message, got %+v", requests[0].Messages)
+	}
+}
+
+func TestGetObjectJSONSchemaWrapsNonObjects(t *testing.T) {
+	var requests []Request
+	server := objectServer(t, &requests,
+		[2]interface{}{http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"{\"value\":[\"red\",\"green\"]}"}}]}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var colors []string
+	err := provider.GetObject(context.Background(), &ai.Config{
+		Model:    "gpt-4o",
+		Messages: []ai.Message{ai.UserMessage("List two colors.")},
+	}, &colors)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(colors, []string{"red", "green"}) {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+
+	schema := requests[0].ResponseFormat.JSONSchema.Schema
+	if schema.Type != "object" || schema.Properties["value"].Type != "array" {
+		t.Errorf("Expected array wrapped in an object, got %+v", schema)
+	}
+}
+
+func TestGetObjectJSONObject(t *testing.T) {
+	var requests []Request
+	server := objectServer(t, &requests,
+		[2]interface{}{http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"{\"name\":\"Ada\",\"hobbies\":[]}"}}]}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var person testPerson
+	err := provider.GetObject(context.Background(), &ai.Config{
+		Model:    "gpt-3.5-turbo",
+		Messages: []ai.Message{ai.UserMessage("Ada Lovelace")},
+	}, &person)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if format := requests[0].ResponseFormat; format == nil || format.Type != "json_object" {
+		t.Errorf("Expected json_object response format, got %+v", format)
+	}
+	if messages := requests[0].Messages; len(messages) != 2 || !strings.Contains(messages[0].Content, "JSON") {
+		t.Errorf("Expected JSON instructions in a system message, got %+v", messages)
+	}
+}
+
+func TestWithInstructions(t *testing.T) {
+	messages := withInstructions([]ai.Message{ai.SystemMessage("Be brief."), ai.UserMessage("Hi")}, "Reply in JSON.")
+	if len(messages) != 2 || messages[0].Content != "Be brief.\n\nReply in JSON." {
+		t.Errorf("Expected the instructions appended to the system message, got %+v", messages)
+	}
+
+	// A system message made of parts gets the instructions as a text part
+	parts := []ai.Message{{Role: ai.RoleSystem, Parts: []ai.Part{ai.TextPart("Be brief.")}}}
+	messages = withInstructions(parts, "Reply in JSON.")
+	if got := messages[0].Parts; len(got) != 2 || got[1].Text != "Reply in JSON." {
+		t.Errorf("Expected the instructions as a text part, got %+v", got)
+	}
+	if len(parts[0].Parts) != 1 {
+		t.Errorf("Expected the original message to be unchanged, got %+v", parts[0])
+	}
+
+	messages = withInstructions([]ai.Message{ai.UserMessage("Hi")}, "Reply in JSON.")
+	if len(messages) != 2 || messages[0].Role != ai.RoleSystem || messages[0].Content != "Reply in JSON." {
+		t.Errorf("Expected a new system message, got %+v", messages)
+	}
+}
+
+func TestGetObjectFallsBackToPrompt(t *testing.T) {
+	var requests []Request
+	server := objectServer(t, &requests,
+		[2]interface{}{http.StatusBadRequest, `{"error":{"message":"Invalid parameter: 'response_format' of type 'json_schema' is not supported with this model.","type":"invalid_request_error","param":"response_format"}}`},
+		[2]interface{}{http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"{\"name\":\"Ada\",\"hobbies\":[]}"}}]}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var person testPerson
+	err := provider.GetObject(context.Background(), &ai.Config{
+		Model:    "gpt-4o-custom",
+		Messages: []ai.Message{ai.UserMessage("Ada Lovelace")},
+	}, &person)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	if requests[1].ResponseFormat != nil {
+		t.Errorf("Expected fallback request without response format, got %+v", requests[1].ResponseFormat)
+	}
+	if person.Name != "Ada" {
+		t.Errorf("Unexpected person: %+v", person)
+	}
+}
+
+func TestGetObjectExplicitMode(t *testing.T) {
+	var requests []Request
+	server := objectServer(t, &requests,
+		[2]interface{}{http.StatusBadRequest, `{"error":{"message":"Invalid parameter: 'response_format'","type":"invalid_request_error","param":"response_format"}}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	// Explicit modes do not fall back
+	var person testPerson
+	err := provider.GetObject(context.Background(), &ai.Config{
+		Model:      "gpt-4",
+		Messages:   []ai.Message{ai.UserMessage("Ada Lovelace")},
+		ObjectMode: ai.ObjectModeSchema,
+	}, &person)
+	if err == nil || len(requests) != 1 {
+		t.Errorf("Expected a single failed request, got err=%v requests=%d", err, len(requests))
+	}
+
+	err = provider.GetObject(context.Background(), &ai.Config{
+		Model:      "gpt-4o",
+		ObjectMode: "unknown",
+	}, &person)
+	if !errors.Is(err, ai.ErrObjectModeNotSupported) {
+		t.Errorf("Expected ErrObjectModeNotSupported, got %v", err)
+	}
+}
+
+func TestGetObjectRefusal(t *testing.T) {
+	server := mockServer(http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":null,"refusal":"I can't help with that."}}]}`)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var person testPerson
+	err := provider.GetObject(context.Background(), &ai.Config{Model: "gpt-4o"}, &person)
+	if !errors.Is(err, ErrRefusal) {
+		t.Errorf("Expected ErrRefusal, got %v", err)
+	}
+}
+
+func TestGenerateObject(t *testing.T) {
+	var requests []Request
+	server := objectServer(t, &requests,
+		[2]interface{}{http.StatusOK, `{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[{"message":{"role":"assistant","content":"{\"name\":\"Ada\",\"nickname\":null,\"hobbies\":[]}"},"finish_reason":"stop"}],"usage":{"prompt_tokens":20,"completion_tokens":8,"total_tokens":28}}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var person testPerson
+	result, err := provider.GenerateObject(context.Background(), &ai.Config{
+		Model:    "gpt-4o",
+		Messages: []ai.Message{ai.UserMessage("Ada Lovelace.")},
+	}, &person)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if person.Name != "Ada" {
+		t.Errorf("Unexpected person: %+v", person)
+	}
+	if result.ID != "chatcmpl-1" || result.Model != "gpt-4o-2024-08-06" || result.FinishReason != ai.FinishReasonStop {
+		t.Errorf("Unexpected metadata: %+v", result)
+	}
+	if result.Usage.InputTokens != 20 || result.Usage.OutputTokens != 8 {
+		t.Errorf("Unexpected usage: %+v", result.Usage)
+	}
+	if !strings.Contains(result.Text, `"name":"Ada"`) {
+		t.Errorf("Expected raw JSON in result text, got %s", result.Text)
+	}
+}
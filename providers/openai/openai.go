@@ -7,10 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/gnfisher/go-ai-sdk"
+	"github.com/gnfisher/go-ai-sdk/internal/retry"
 )
 
 const (
@@ -20,6 +22,7 @@ const (
 var (
 	ErrEmptyAPIKey     = errors.New("OpenAI API key is empty")
 	ErrInvalidResponse = errors.New("invalid response from OpenAI API")
+	ErrRefusal         = errors.New("OpenAI model refused the request")
 )
 
 // Provider implements the ai.LLMProvider interface for OpenAI
@@ -27,6 +30,8 @@ type Provider struct {
 	apiKey string
 	apiURL string
 	client *http.Client
+	retry  ai.RetryPolicy
+	logger *slog.Logger
 }
 
 // Option is a function that configures the OpenAI provider
@@ -53,6 +58,22 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithRetry sets the policy for retrying requests that fail with a transient error.
+// It can be overridden per request with ai.WithRetry.
+func WithRetry(policy ai.RetryPolicy) Option {
+	return func(p *Provider) {
+		p.retry = policy
+	}
+}
+
+// WithLogger sets the logger for HTTP requests and retries.
+// It can be overridden per request with ai.WithLogger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger
+	}
+}
+
 // New creates a new OpenAI provider
 func New(options ...Option) *Provider {
 	provider := &Provider{
@@ -67,10 +88,96 @@ func New(options ...Option) *Provider {
 	return provider
 }
 
-// Message represents an OpenAI chat message
+// Message represents an OpenAI chat message.
+// When Parts is set, it is sent as the content instead of Content.
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string        `json:"role"`
+	Content    string        `json:"content"`
+	Parts      []ContentPart `json:"-"`
+	Refusal    string        `json:"refusal,omitempty"`
+	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`
+	ToolCallID string        `json:"tool_call_id,omitempty"`
+}
+
+// message has the fields of Message without its JSON methods
+type message Message
+
+// MarshalJSON encodes the content as an array of parts when Parts is set
+func (m Message) MarshalJSON() ([]byte, error) {
+	if len(m.Parts) == 0 {
+		return json.Marshal(message(m))
+	}
+
+	return json.Marshal(struct {
+		message
+		Content []ContentPart `json:"content"`
+	}{message(m), m.Parts})
+}
+
+// UnmarshalJSON decodes content that is either a string or an array of parts
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		message
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*m = Message(raw.message)
+	if len(raw.Content) > 0 && raw.Content[0] == '[' {
+		return json.Unmarshal(raw.Content, &m.Parts)
+	}
+	if len(raw.Content) > 0 && string(raw.Content) != "null" {
+		return json.Unmarshal(raw.Content, &m.Content)
+	}
+	return nil
+}
+
+// ContentPart is a part of a multimodal message
+type ContentPart struct {
+	Type     string    `json:"type"` // "text", "image_url" or "file"
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+	File     *File     `json:"file,omitempty"`
+}
+
+// ImageURL references an image by URL or data URL
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// File holds an inline file, such as a PDF
+type File struct {
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data,omitempty"`
+}
+
+// Tool represents a tool definition in an OpenAI request
+type Tool struct {
+	Type     string   `json:"type"`
+	Function Function `json:"function"`
+}
+
+// Function describes a function the model may call
+type Function struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ToolCall represents a tool call made by the model
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall holds the name and JSON-encoded arguments of a function call
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
 // Request represents a request to the OpenAI API
@@ -79,6 +186,31 @@ type Request struct {
 	Messages    []Message `json:"messages"`
 	Temperature float64   `json:"temperature,omitempty"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Tools       []Tool    `json:"tools,omitempty"`
+
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions configures a streamed OpenAI response
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ResponseFormat constrains the format of the model's output
+type ResponseFormat struct {
+	Type       string      `json:"type"` // "text", "json_object" or "json_schema"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema is the schema for a json_schema response format
+type JSONSchema struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Schema      *ai.Schema `json:"schema"`
+	Strict      bool       `json:"strict,omitempty"`
 }
 
 // Response represents a response from the OpenAI API
@@ -86,10 +218,26 @@ type Response struct {
 	ID      string   `json:"id"`
 	Object  string   `json:"object"`
 	Created int      `json:"created"`
+	Model   string   `json:"model,omitempty"`
 	Choices []Choice `json:"choices"`
+	Usage   *Usage   `json:"usage,omitempty"`
 	Error   *Error   `json:"error,omitempty"`
 }
 
+// Usage represents token usage in the OpenAI API response
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+
+	PromptTokensDetails *PromptTokensDetails `json:"prompt_tokens_details,omitempty"`
+}
+
+// PromptTokensDetails breaks down the prompt tokens in the OpenAI API response
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
 // Choice represents a choice in the OpenAI API response
 type Choice struct {
 	Index        int     `json:"index"`
@@ -110,135 +258,348 @@ func convertMessages(messages []ai.Message) []Message {
 	result := make([]Message, len(messages))
 	for i, msg := range messages {
 		result[i] = Message{
-			Role:    string(msg.Role),
-			Content: msg.Content,
+			Role:       string(msg.Role),
+			Content:    msg.Content,
+			Parts:      convertParts(msg.Parts),
+			ToolCallID: msg.ToolCallID,
+		}
+
+		for _, call := range msg.ToolCalls {
+			result[i].ToolCalls = append(result[i].ToolCalls, ToolCall{
+				ID:   call.ID,
+				Type: "function",
+				Function: FunctionCall{
+					Name:      call.Name,
+					Arguments: string(call.Arguments),
+				},
+			})
 		}
 	}
 	return result
 }
 
-// GetText gets a text response from the OpenAI API
-func (p *Provider) GetText(ctx context.Context, config *ai.Config) (string, error) {
-	if p.apiKey == "" {
-		return "", ErrEmptyAPIKey
+// convertParts converts ai.Part to openai.ContentPart. The parts must have passed checkContent.
+func convertParts(parts []ai.Part) []ContentPart {
+	if len(parts) == 0 {
+		return nil
+	}
+
+	result := make([]ContentPart, len(parts))
+	for i, part := range parts {
+		switch part.Type {
+		case ai.PartTypeText:
+			result[i] = ContentPart{Type: "text", Text: part.Text}
+
+		case ai.PartTypeImage:
+			url := part.URL
+			if url == "" {
+				url = dataURL(part)
+			}
+			result[i] = ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: url}}
+
+		case ai.PartTypeDocument:
+			filename := part.Name
+			if filename == "" {
+				filename = "document.pdf"
+			}
+			result[i] = ContentPart{Type: "file", File: &File{Filename: filename, FileData: dataURL(part)}}
+		}
+	}
+	return result
+}
+
+// dataURL encodes a part's data as a data URL
+func dataURL(part ai.Part) string {
+	return "data:" + part.MediaType + ";base64," + part.Data
+}
+
+// checkContent reports an error if the messages hold content parts OpenAI cannot accept
+func checkContent(messages []ai.Message) error {
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			if part.Type != ai.PartTypeText && msg.Role != ai.RoleUser {
+				return fmt.Errorf("%w: OpenAI only accepts %s parts in user messages", ai.ErrUnsupportedContent, part.Type)
+			}
+
+			switch part.Type {
+			case ai.PartTypeText:
+			case ai.PartTypeImage:
+				if part.URL == "" && part.MediaType == "" {
+					return fmt.Errorf("%w: image data has no media type", ai.ErrUnsupportedContent)
+				}
+			case ai.PartTypeDocument:
+				if part.URL != "" {
+					return fmt.Errorf("%w: OpenAI does not accept document URLs", ai.ErrUnsupportedContent)
+				}
+				if part.MediaType != "application/pdf" {
+					return fmt.Errorf("%w: OpenAI does not accept %q documents", ai.ErrUnsupportedContent, part.MediaType)
+				}
+			default:
+				return fmt.Errorf("%w: unknown part type %q", ai.ErrUnsupportedContent, part.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// convertTools converts ai.Tool to openai.Tool
+func convertTools(tools []ai.Tool) []Tool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	result := make([]Tool, len(tools))
+	for i, tool := range tools {
+		result[i] = Tool{
+			Type: "function",
+			Function: Function{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		}
+	}
+	return result
+}
+
+// convertFinishReason converts an OpenAI finish_reason to ai.FinishReason
+func convertFinishReason(reason string) ai.FinishReason {
+	switch reason {
+	case "stop":
+		return ai.FinishReasonStop
+	case "length":
+		return ai.FinishReasonLength
+	case "tool_calls", "function_call":
+		return ai.FinishReasonToolCalls
+	case "content_filter":
+		return ai.FinishReasonContentFilter
+	case "":
+		return ""
+	default:
+		return ai.FinishReasonOther
+	}
+}
+
+// convertUsage converts OpenAI usage to ai.Usage
+func convertUsage(usage *Usage) ai.Usage {
+	if usage == nil {
+		return ai.Usage{}
+	}
+	converted := ai.Usage{
+		InputTokens:  usage.PromptTokens,
+		OutputTokens: usage.CompletionTokens,
+	}
+	if usage.PromptTokensDetails != nil {
+		converted.CachedInputTokens = usage.PromptTokensDetails.CachedTokens
+	}
+	return converted
+}
+
+// convertToolCalls converts openai.ToolCall to ai.ToolCall
+func convertToolCalls(calls []ToolCall) []ai.ToolCall {
+	if len(calls) == 0 {
+		return nil
 	}
 
-	openaiMessages := convertMessages(config.Messages)
+	result := make([]ai.ToolCall, len(calls))
+	for i, call := range calls {
+		result[i] = ai.ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: json.RawMessage(call.Function.Arguments),
+		}
+	}
+	return result
+}
 
-	reqBody := Request{
+// newRequest builds an OpenAI request from the config
+func newRequest(config *ai.Config) Request {
+	return Request{
 		Model:       config.Model,
-		Messages:    openaiMessages,
+		Messages:    convertMessages(config.Messages),
 		Temperature: config.Temperature,
 		MaxTokens:   config.MaxTokens,
+		Tools:       convertTools(config.Tools),
 	}
+}
 
+// do posts a request to the OpenAI API and returns the response if it succeeded.
+// The caller must close the response body.
+func (p *Provider) do(ctx context.Context, config *ai.Config, reqBody Request) (*http.Response, error) {
 	reqJSON, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewBuffer(reqJSON))
+	logger := p.loggerFor(config)
+	start := time.Now()
+	var sent *http.Request
+
+	resp, err := retry.Do(ctx, p.client, p.retryPolicy(config), logger, shouldRetry, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewReader(reqJSON))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+		sent = req
+		return req, nil
+	})
+	retry.LogResponse(ctx, logger, sent, resp, err, time.Since(start))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	if config.RateLimitObserver != nil {
+		if status, ok := retry.RateLimits(resp.Header, time.Now()); ok {
+			config.RateLimitObserver(status)
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
 
-	resp, err := p.client.Do(req)
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		return nil, newAPIError(resp, body)
+	}
+
+	return resp, nil
+}
+
+// loggerFor returns the logger for a request, or nil if it is not logged
+func (p *Provider) loggerFor(config *ai.Config) *slog.Logger {
+	logger := p.logger
+	if config.Logger != nil {
+		logger = config.Logger
+	}
+	if logger == nil {
+		return nil
+	}
+	return logger.With(slog.String("provider", string(ai.ProviderOpenAI)))
+}
+
+// retryPolicy returns the retry policy for a request
+func (p *Provider) retryPolicy(config *ai.Config) ai.RetryPolicy {
+	if config.Retry != nil {
+		return *config.Retry
+	}
+	return p.retry
+}
+
+// send posts a request to the OpenAI API and decodes the response
+func (p *Provider) send(ctx context.Context, config *ai.Config, reqBody Request) (*Response, error) {
+	resp, err := p.do(ctx, config, reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		var errResp Response
-		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
-			return "", fmt.Errorf("OpenAI API error: %s", errResp.Error.Message)
-		}
-		return "", fmt.Errorf("OpenAI API returned status code %d: %s", resp.StatusCode, body)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var openAIResp Response
 	if err := json.Unmarshal(body, &openAIResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	if len(openAIResp.Choices) == 0 || openAIResp.Choices[0].Message.Content == "" {
-		return "", ErrInvalidResponse
-	}
-
-	return openAIResp.Choices[0].Message.Content, nil
+	return &openAIResp, nil
 }
 
-// GetObject gets a structured response from the OpenAI API
-func (p *Provider) GetObject(ctx context.Context, config *ai.Config, target interface{}) error {
+// GenerateText gets a full result, including any tool calls, from the OpenAI API
+func (p *Provider) GenerateText(ctx context.Context, config *ai.Config) (*ai.Result, error) {
 	if p.apiKey == "" {
-		return ErrEmptyAPIKey
+		return nil, ErrEmptyAPIKey
+	}
+
+	if err := checkContent(config.Messages); err != nil {
+		return nil, err
 	}
 
-	// Extract the type information from the target
-	targetType := fmt.Sprintf("%T", target)
+	return p.generate(ctx, config, newRequest(config))
+}
 
-	// Create a system message instructing the model to return JSON
-	systemMsg := ai.Message{
-		Role:    ai.RoleSystem,
-		Content: fmt.Sprintf("You are a helpful assistant that responds with JSON matching the %s type. Your response should be valid JSON and nothing else.", targetType),
+// generate sends a request and converts the first choice to an ai.Result
+func (p *Provider) generate(ctx context.Context, config *ai.Config, reqBody Request) (*ai.Result, error) {
+	openAIResp, err := p.send(ctx, config, reqBody)
+	if err != nil {
+		return nil, err
 	}
 
-	// Prepare messages, adding the system message if not already present
-	messages := config.Messages
-	hasSystemMsg := false
-	for _, msg := range messages {
-		if msg.Role == ai.RoleSystem {
-			hasSystemMsg = true
-			break
-		}
+	if len(openAIResp.Choices) == 0 {
+		return nil, ErrInvalidResponse
 	}
 
-	if !hasSystemMsg {
-		messages = append([]ai.Message{systemMsg}, messages...)
+	choice := openAIResp.Choices[0]
+	if choice.Message.Refusal != "" {
+		return nil, fmt.Errorf("%w: %s", ErrRefusal, choice.Message.Refusal)
 	}
 
-	// Get the text response
-	textResp, err := p.GetText(ctx, &ai.Config{
-		Provider:    config.Provider,
-		Model:       config.Model,
-		Messages:    messages,
-		MaxTokens:   config.MaxTokens,
-		Temperature: config.Temperature,
-	})
+	if choice.Message.Content == "" && len(choice.Message.ToolCalls) == 0 {
+		return nil, ErrInvalidResponse
+	}
+
+	return &ai.Result{
+		Text:         choice.Message.Content,
+		ToolCalls:    convertToolCalls(choice.Message.ToolCalls),
+		FinishReason: convertFinishReason(choice.FinishReason),
+		Usage:        convertUsage(openAIResp.Usage),
+		ID:           openAIResp.ID,
+		Model:        openAIResp.Model,
+	}, nil
+}
+
+// GetText gets a text response from the OpenAI API
+func (p *Provider) GetText(ctx context.Context, config *ai.Config) (string, error) {
+	result, err := p.GenerateText(ctx, config)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// Clean the response to ensure it's valid JSON
-	jsonStr := strings.TrimSpace(textResp)
+	if result.Text == "" {
+		return "", ErrInvalidResponse
+	}
 
-	// If response starts with ``` (markdown code block), clean it up
-	if strings.HasPrefix(jsonStr, "```json") {
-		jsonStr = strings.TrimPrefix(jsonStr, "```json")
-		if idx := strings.LastIndex(jsonStr, "```"); idx != -1 {
-			jsonStr = jsonStr[:idx]
-		}
-	} else if strings.HasPrefix(jsonStr, "```") {
-		jsonStr = strings.TrimPrefix(jsonStr, "```")
-		if idx := strings.LastIndex(jsonStr, "```"); idx != -1 {
-			jsonStr = jsonStr[:idx]
-		}
+	return result.Text, nil
+}
+
+// GetObject gets a structured response from the OpenAI API
+func (p *Provider) GetObject(ctx context.Context, config *ai.Config, target interface{}) error {
+	_, err := p.GenerateObject(ctx, config, target)
+	return err
+}
+
+// GenerateObject gets a structured response from the OpenAI API along with its metadata
+func (p *Provider) GenerateObject(ctx context.Context, config *ai.Config, target interface{}) (*ai.Result, error) {
+	if p.apiKey == "" {
+		return nil, ErrEmptyAPIKey
 	}
 
-	jsonStr = strings.TrimSpace(jsonStr)
+	if err := checkContent(config.Messages); err != nil {
+		return nil, err
+	}
 
-	// Unmarshal the JSON into the target
-	if err := json.Unmarshal([]byte(jsonStr), target); err != nil {
-		return fmt.Errorf("failed to unmarshal JSON response: %w: %s", err, jsonStr)
+	mode := config.ObjectMode
+	if mode == ai.ObjectModeAuto {
+		mode = defaultObjectMode(config.Model)
 	}
 
-	return nil
+	result, err := p.generateObject(ctx, config, target, mode)
+
+	// Fall back to the prompt-based mode if the model rejects the response format
+	if err != nil && config.ObjectMode == ai.ObjectModeAuto && mode != ai.ObjectModePrompt && isResponseFormatError(err) {
+		result, err = p.generateObject(ctx, config, target, ai.ObjectModePrompt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode and validate the response
+	return result, ai.DecodeObject(result.Text, target)
 }
@@ -1,11 +1,17 @@
 package openai
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gnfisher/go-ai-sdk"
 )
@@ -215,3 +221,411 @@ func TestGetObject(t *testing.T) {
 		t.Errorf("Expected 'Hello, world!', got %s", resp.Message)
 	}
 }
+
+func TestGenerateTextWithTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+
+		if len(req.Tools) != 1 || req.Tools[0].Type != "function" || req.Tools[0].Function.Name != "get_weather" {
+			t.Errorf("Expected get_weather function tool, got %+v", req.Tools)
+		}
+
+		// The tool result should be sent back with role "tool"
+		last := req.Messages[len(req.Messages)-1]
+		if last.Role != "tool" || last.ToolCallID != "call_0" {
+			t.Errorf("Expected tool message answering call_0, got %+v", last)
+		}
+
+		resp := Response{
+			Choices: []Choice{
+				{
+					Message: Message{
+						Role: "assistant",
+						ToolCalls: []ToolCall{
+							{
+								ID:   "call_1",
+								Type: "function",
+								Function: FunctionCall{
+									Name:      "get_weather",
+									Arguments: `{"city":"Paris"}`,
+								},
+							},
+						},
+					},
+					FinishReason: "tool_calls",
+				},
+			},
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Fatalf("failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	result, err := provider.GenerateText(context.Background(), &ai.Config{
+		Model: "test-model",
+		Messages: []ai.Message{
+			ai.UserMessage("What's the weather in London?"),
+			ai.AssistantToolCallMessage("", ai.ToolCall{ID: "call_0", Name: "get_weather", Arguments: json.RawMessage(`{"city":"London"}`)}),
+			ai.ToolResultMessage("call_0", "Rainy"),
+		},
+		Tools: []ai.Tool{
+			{
+				Name:        "get_weather",
+				Description: "Get the weather for a city",
+				Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(result.ToolCalls))
+	}
+
+	call := result.ToolCalls[0]
+	if call.ID != "call_1" || call.Name != "get_weather" || string(call.Arguments) != `{"city":"Paris"}` {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+}
+
+func TestConvertMessagesWithToolCalls(t *testing.T) {
+	messages := convertMessages([]ai.Message{
+		ai.AssistantToolCallMessage("", ai.ToolCall{ID: "call_1", Name: "get_weather", Arguments: json.RawMessage(`{"city":"Paris"}`)}),
+		ai.ToolResultMessage("call_1", "Sunny"),
+	})
+
+	if len(messages[0].ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(messages[0].ToolCalls))
+	}
+
+	call := messages[0].ToolCalls[0]
+	if call.Type != "function" || call.Function.Name != "get_weather" || call.Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+
+	if messages[1].Role != "tool" || messages[1].ToolCallID != "call_1" || messages[1].Content != "Sunny" {
+		t.Errorf("Unexpected tool message: %+v", messages[1])
+	}
+}
+
+func TestGenerateTextMetadata(t *testing.T) {
+	server := mockServer(http.StatusOK, `{
+		"id": "chatcmpl-123",
+		"object": "chat.completion",
+		"model": "gpt-4o-2024-08-06",
+		"choices": [{"index": 0, "message": {"role": "assistant", "content": "Once upon a"}, "finish_reason": "length"}],
+		"usage": {"prompt_tokens": 12, "completion_tokens": 3, "total_tokens": 15}
+	}`)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	result, err := provider.GenerateText(context.Background(), &ai.Config{
+		Model:    "gpt-4o",
+		Messages: []ai.Message{ai.UserMessage("Tell me a story")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.FinishReason != ai.FinishReasonLength {
+		t.Errorf("Expected finish reason length, got %s", result.FinishReason)
+	}
+	if result.Usage.InputTokens != 12 || result.Usage.OutputTokens != 3 || result.Usage.TotalTokens() != 15 {
+		t.Errorf("Unexpected usage: %+v", result.Usage)
+	}
+	if result.ID != "chatcmpl-123" {
+		t.Errorf("Expected ID chatcmpl-123, got %s", result.ID)
+	}
+	if result.Model != "gpt-4o-2024-08-06" {
+		t.Errorf("Expected model gpt-4o-2024-08-06, got %s", result.Model)
+	}
+}
+
+func TestConvertUsage(t *testing.T) {
+	var usage Usage
+	if err := json.Unmarshal([]byte(`{
+		"prompt_tokens": 2000,
+		"completion_tokens": 100,
+		"total_tokens": 2100,
+		"prompt_tokens_details": {"cached_tokens": 1536}
+	}`), &usage); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ai.Usage{InputTokens: 2000, OutputTokens: 100, CachedInputTokens: 1536}
+	if got := convertUsage(&usage); got != expected {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestConvertFinishReason(t *testing.T) {
+	tests := map[string]ai.FinishReason{
+		"stop":           ai.FinishReasonStop,
+		"length":         ai.FinishReasonLength,
+		"tool_calls":     ai.FinishReasonToolCalls,
+		"function_call":  ai.FinishReasonToolCalls,
+		"content_filter": ai.FinishReasonContentFilter,
+		"something_new":  ai.FinishReasonOther,
+		"":               "",
+	}
+
+	for reason, expected := range tests {
+		if got := convertFinishReason(reason); got != expected {
+			t.Errorf("convertFinishReason(%q) = %s, want %s", reason, got, expected)
+		}
+	}
+}
+
+func TestGetObjectSchemaPrompt(t *testing.T) {
+	type Person struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+
+		// The schema is appended to the caller's system message
+		if len(req.Messages) != 2 || req.Messages[0].Role != "system" {
+			t.Fatalf("Expected a system and a user message, got %+v", req.Messages)
+		}
+		system := req.Messages[0].Content
+		if !strings.HasPrefix(system, "You extract people.") {
+			t.Errorf("Expected caller's system message to be kept, got %s", system)
+		}
+		if !strings.Contains(system, `"required":["name","age"]`) {
+			t.Errorf("Expected system message to contain the schema, got %s", system)
+		}
+
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"{\"name\":\"Ada\",\"age\":36}"}}]}`)
+	}))
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var person Person
+	err := provider.GetObject(context.Background(), &ai.Config{
+		Model: "test-model",
+		Messages: []ai.Message{
+			ai.SystemMessage("You extract people."),
+			ai.UserMessage("Ada Lovelace was 36."),
+		},
+	}, &person)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if person.Name != "Ada" || person.Age != 36 {
+		t.Errorf("Unexpected person: %+v", person)
+	}
+}
+
+func TestConvertMessagesWithParts(t *testing.T) {
+	messages := convertMessages([]ai.Message{
+		ai.UserMessageParts(
+			ai.TextPart("Summarize these."),
+			ai.ImageBase64Part("aGVsbG8=", "image/png"),
+			ai.ImageURLPart("https://example.com/cat.jpg"),
+			ai.DocumentPart([]byte("%PDF"), "application/pdf", "report.pdf"),
+		),
+	})
+
+	body, err := json.Marshal(messages[0])
+	if err != nil {
+		t.Fatalf("failed to marshal message: %v", err)
+	}
+
+	expected := `{"role":"user","content":[` +
+		`{"type":"text","text":"Summarize these."},` +
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,aGVsbG8="}},` +
+		`{"type":"image_url","image_url":{"url":"https://example.com/cat.jpg"}},` +
+		`{"type":"file","file":{"filename":"report.pdf","file_data":"data:application/pdf;base64,JVBERg=="}}]}`
+	if string(body) != expected {
+		t.Errorf("Unexpected message JSON:\n got %s\nwant %s", body, expected)
+	}
+
+	// The parts survive a round trip
+	var decoded Message
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal message: %v", err)
+	}
+	if len(decoded.Parts) != 4 || decoded.Parts[1].ImageURL == nil {
+		t.Errorf("Unexpected decoded message: %+v", decoded)
+	}
+
+	// Plain messages still use a string
+	body, _ = json.Marshal(convertMessages([]ai.Message{ai.UserMessage("Hi")})[0])
+	if string(body) != `{"role":"user","content":"Hi"}` {
+		t.Errorf("Unexpected message JSON: %s", body)
+	}
+}
+
+func TestUnsupportedContent(t *testing.T) {
+	tests := map[string]ai.Message{
+		"image in assistant message": {Role: ai.RoleAssistant, Parts: []ai.Part{ai.ImageURLPart("https://example.com/cat.jpg")}},
+		"document URL":               ai.UserMessageParts(ai.DocumentURLPart("https://example.com/report.pdf")),
+		"non-PDF document":           ai.UserMessageParts(ai.DocumentPart([]byte("a,b"), "text/csv", "data.csv")),
+		"image without media type":   ai.UserMessageParts(ai.ImageBase64Part("aGVsbG8=", "")),
+	}
+
+	provider := New(WithAPIKey("test-key"), WithAPIURL("http://localhost:0"))
+
+	for name, msg := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := provider.GenerateText(context.Background(), &ai.Config{
+				Model:    "gpt-4o",
+				Messages: []ai.Message{msg},
+			})
+			if !errors.Is(err, ai.ErrUnsupportedContent) {
+				t.Errorf("Expected ErrUnsupportedContent, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var requests []Request
+	server := objectServer(t, &requests,
+		[2]interface{}{http.StatusTooManyRequests, `{"error":{"message":"Rate limit reached","type":"requests"}}`},
+		[2]interface{}{http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"Hello!"}}]}`},
+		[2]interface{}{http.StatusServiceUnavailable, `{"error":{"message":"Overloaded","type":"server_error"}}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+		WithRetry(ai.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}),
+	)
+
+	text, err := provider.GetText(context.Background(), &ai.Config{
+		Model:    "gpt-4o",
+		Messages: []ai.Message{ai.UserMessage("Hello")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "Hello!" || len(requests) != 2 {
+		t.Errorf("Expected success on the second attempt, got %q after %d requests", text, len(requests))
+	}
+
+	// The per-call policy overrides the provider's
+	_, err = provider.GetText(context.Background(), &ai.Config{
+		Model:    "gpt-4o",
+		Messages: []ai.Message{ai.UserMessage("Hello")},
+		Retry:    &ai.RetryPolicy{MaxAttempts: 1},
+	})
+	if err == nil || len(requests) != 3 {
+		t.Errorf("Expected a single failed attempt, got %v after %d requests", err, len(requests))
+	}
+}
+
+func TestRetryQuotaExceeded(t *testing.T) {
+	var requests []Request
+	server := objectServer(t, &requests,
+		[2]interface{}{http.StatusTooManyRequests, `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`},
+		[2]interface{}{http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"Hello!"}}]}`},
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+		WithRetry(ai.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}),
+	)
+
+	// Unlike a rate limit, an exhausted quota is not retried
+	_, err := provider.GetText(context.Background(), &ai.Config{
+		Model:    "gpt-4o",
+		Messages: []ai.Message{ai.UserMessage("Hello")},
+	})
+	if !errors.Is(err, ai.ErrQuotaExceeded) || len(requests) != 1 {
+		t.Errorf("Expected a single attempt failing with ErrQuotaExceeded, got %v after %d requests", err, len(requests))
+	}
+}
+
+func TestRateLimitObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-ratelimit-limit-requests", "500")
+		w.Header().Set("x-ratelimit-remaining-requests", "499")
+		w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	provider := New(WithAPIKey("test-key"), WithAPIURL(server.URL))
+
+	var status ai.RateLimitStatus
+	_, err := provider.GetText(context.Background(), &ai.Config{
+		Model:             "gpt-4o",
+		Messages:          []ai.Message{ai.UserMessage("Hello")},
+		RateLimitObserver: func(s ai.RateLimitStatus) { status = s },
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.RequestsLimit != 500 || status.RequestsRemaining != 499 {
+		t.Errorf("Unexpected rate limit status: %+v", status)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("retry-after", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("x-request-id", "req_123")
+		w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	provider := New(
+		WithAPIKey("sk-secret-key-123456"),
+		WithAPIURL(server.URL),
+		WithRetry(ai.RetryPolicy{MaxAttempts: 2}),
+		WithLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+	)
+
+	if _, err := provider.GetText(context.Background(), &ai.Config{
+		Model:    "gpt-4o",
+		Messages: []ai.Message{ai.UserMessage("Hello")},
+	}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	logs := buf.String()
+	if !strings.Contains(logs, `level=WARN msg="retrying request" provider=openai attempt=1`) {
+		t.Errorf("Expected the retry to be logged, got %s", logs)
+	}
+	if !strings.Contains(logs, `msg="provider response" provider=openai`) || !strings.Contains(logs, "status=200") || !strings.Contains(logs, "request_id=req_123") {
+		t.Errorf("Expected the response to be logged, got %s", logs)
+	}
+	if strings.Contains(logs, "sk-secret-key") || !strings.Contains(logs, "[REDACTED]") {
+		t.Errorf("Expected the API key to be redacted, got %s", logs)
+	}
+}
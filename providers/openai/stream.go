@@ -0,0 +1,160 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gnfisher/go-ai-sdk"
+	"github.com/gnfisher/go-ai-sdk/internal/sse"
+)
+
+// StreamChunk represents a chunk of a streamed OpenAI response
+type StreamChunk struct {
+	ID      string         `json:"id"`
+	Object  string         `json:"object"`
+	Created int            `json:"created"`
+	Model   string         `json:"model,omitempty"`
+	Choices []StreamChoice `json:"choices"`
+	Usage   *Usage         `json:"usage,omitempty"`
+	Error   *Error         `json:"error,omitempty"`
+}
+
+// StreamChoice represents a choice in a streamed chunk
+type StreamChoice struct {
+	Index        int         `json:"index"`
+	Delta        ChoiceDelta `json:"delta"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// ChoiceDelta holds the incremental message content of a streamed choice
+type ChoiceDelta struct {
+	Role      string          `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	ToolCalls []ToolCallChunk `json:"tool_calls,omitempty"`
+}
+
+// ToolCallChunk is a fragment of a streamed tool call
+type ToolCallChunk struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// streamReader reads deltas from an OpenAI response stream
+type streamReader struct {
+	ctx     context.Context
+	body    io.ReadCloser
+	events  *sse.Reader
+	pending []ai.Delta
+	started bool
+}
+
+// StreamText streams a response from the OpenAI API
+func (p *Provider) StreamText(ctx context.Context, config *ai.Config) (*ai.Stream, error) {
+	if p.apiKey == "" {
+		return nil, ErrEmptyAPIKey
+	}
+
+	if err := checkContent(config.Messages); err != nil {
+		return nil, err
+	}
+
+	reqBody := newRequest(config)
+	reqBody.Stream = true
+	reqBody.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	resp, err := p.do(ctx, config, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	return ai.NewStream(newStreamReader(ctx, resp)), nil
+}
+
+// newStreamReader creates a streamReader for a successful streaming response
+func newStreamReader(ctx context.Context, resp *http.Response) *streamReader {
+	return &streamReader{
+		ctx:    ctx,
+		body:   resp.Body,
+		events: sse.NewReader(resp.Body),
+	}
+}
+
+// Recv returns the next delta from the stream
+func (r *streamReader) Recv() (ai.Delta, error) {
+	for len(r.pending) == 0 {
+		event, err := r.events.Next()
+		if err != nil {
+			if ctxErr := r.ctx.Err(); ctxErr != nil {
+				return ai.Delta{}, ctxErr
+			}
+			if err == io.EOF {
+				return ai.Delta{}, io.ErrUnexpectedEOF
+			}
+			return ai.Delta{}, fmt.Errorf("failed to read stream: %w", err)
+		}
+
+		if event.Data == "[DONE]" {
+			return ai.Delta{}, io.EOF
+		}
+
+		var chunk StreamChunk
+		if err := json.Unmarshal([]byte(event.Data), &chunk); err != nil {
+			return ai.Delta{}, fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+
+		if chunk.Error != nil {
+			return ai.Delta{}, streamError(chunk.Error, []byte(event.Data))
+		}
+
+		// Metadata is reported once, on the first chunk, followed by the
+		// finish reason and usage on the chunks where they appear
+		if !r.started {
+			r.started = true
+			r.pending = append(r.pending, ai.Delta{ID: chunk.ID, Model: chunk.Model})
+		}
+
+		if chunk.Usage != nil {
+			usage := convertUsage(chunk.Usage)
+			r.pending = append(r.pending, ai.Delta{Usage: &usage})
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Index != 0 {
+				continue
+			}
+
+			if choice.Delta.Content != "" {
+				r.pending = append(r.pending, ai.Delta{Text: choice.Delta.Content})
+			}
+
+			for _, call := range choice.Delta.ToolCalls {
+				r.pending = append(r.pending, ai.Delta{
+					ToolCall: &ai.ToolCallDelta{
+						Index:     call.Index,
+						ID:        call.ID,
+						Name:      call.Function.Name,
+						Arguments: call.Function.Arguments,
+					},
+				})
+			}
+
+			if choice.FinishReason != "" {
+				r.pending = append(r.pending, ai.Delta{FinishReason: convertFinishReason(choice.FinishReason)})
+			}
+		}
+	}
+
+	delta := r.pending[0]
+	r.pending = r.pending[1:]
+	return delta, nil
+}
+
+// Close closes the response body
+func (r *streamReader) Close() error {
+	return r.body.Close()
+}
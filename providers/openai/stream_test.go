@@ -0,0 +1,222 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+// streamServer creates a test server that streams the given chunks as server-sent events
+func streamServer(t *testing.T, chunks ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("Expected stream with usage to be requested")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+	}))
+}
+
+func TestStreamText(t *testing.T) {
+	server := streamServer(t,
+		`{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[{"index":0,"delta":{"content":"Hello"}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[{"index":0,"delta":{"content":", world!"}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[],"usage":{"prompt_tokens":9,"completion_tokens":4,"total_tokens":13}}`,
+		`[DONE]`,
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	stream, err := provider.StreamText(context.Background(), &ai.Config{
+		Model:    "test-model",
+		Messages: []ai.Message{ai.UserMessage("Hello")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	var deltas []string
+	for stream.Next() {
+		deltas = append(deltas, stream.Delta().Text)
+	}
+
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Expected no stream error, got %v", err)
+	}
+
+	if len(deltas) != 2 || deltas[0] != "Hello" || deltas[1] != ", world!" {
+		t.Errorf("Unexpected deltas: %q", deltas)
+	}
+
+	result := stream.Result()
+	if result.Text != "Hello, world!" {
+		t.Errorf("Expected 'Hello, world!', got %s", result.Text)
+	}
+	if result.ID != "chatcmpl-1" || result.Model != "gpt-4o-2024-08-06" {
+		t.Errorf("Unexpected response metadata: id=%s model=%s", result.ID, result.Model)
+	}
+	if result.FinishReason != ai.FinishReasonStop {
+		t.Errorf("Expected finish reason stop, got %s", result.FinishReason)
+	}
+	if result.Usage.InputTokens != 9 || result.Usage.OutputTokens != 4 {
+		t.Errorf("Unexpected usage: %+v", result.Usage)
+	}
+}
+
+func TestStreamTextToolCalls(t *testing.T) {
+	server := streamServer(t,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`[DONE]`,
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	stream, err := provider.StreamText(context.Background(), &ai.Config{
+		Model:    "test-model",
+		Messages: []ai.Message{ai.UserMessage("What's the weather in Paris?")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+	}
+
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Expected no stream error, got %v", err)
+	}
+
+	result := stream.Result()
+	if len(result.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(result.ToolCalls))
+	}
+
+	call := result.ToolCalls[0]
+	if call.ID != "call_1" || call.Name != "get_weather" || string(call.Arguments) != `{"city":"Paris"}` {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+}
+
+func TestStreamTextCancel(t *testing.T) {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := provider.StreamText(ctx, &ai.Config{
+		Model:    "test-model",
+		Messages: []ai.Message{ai.UserMessage("Hello")},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	if !stream.Next() || stream.Delta().Text != "Hello" {
+		t.Fatalf("Expected first delta 'Hello'")
+	}
+
+	<-started
+	cancel()
+
+	if stream.Next() {
+		t.Errorf("Expected stream to stop after cancellation")
+	}
+	if !errors.Is(stream.Err(), context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", stream.Err())
+	}
+}
+
+func TestStreamTextError(t *testing.T) {
+	server := mockServer(http.StatusUnauthorized, `{"error":{"message":"Invalid API key","type":"invalid_request_error"}}`)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	_, err := provider.StreamText(context.Background(), &ai.Config{Model: "test-model"})
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestStreamObjectUnwrapsNonObjects(t *testing.T) {
+	server := streamServer(t,
+		`{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[{"index":0,"delta":{"content":"{\"value\":[\"re"}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[{"index":0,"delta":{"content":"d\",\"green\"]"}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[{"index":0,"delta":{"content":"}"},"finish_reason":"stop"}]}`,
+		`[DONE]`,
+	)
+	defer server.Close()
+
+	provider := New(
+		WithAPIKey("test-key"),
+		WithAPIURL(server.URL),
+	)
+
+	var colors []string
+	stream, err := provider.StreamObject(context.Background(), &ai.Config{
+		Model:    "gpt-4o",
+		Messages: []ai.Message{ai.UserMessage("Name two colors.")},
+	}, &colors)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	result := stream.Result()
+	if result.Text != `["red","green"]` {
+		t.Errorf("Expected unwrapped JSON, got %s", result.Text)
+	}
+	if result.FinishReason != ai.FinishReasonStop {
+		t.Errorf("Expected finish reason stop, got %s", result.FinishReason)
+	}
+}
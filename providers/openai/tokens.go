@@ -0,0 +1,156 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+const (
+	encodingCL100K = "cl100k_base"
+	encodingO200K  = "o200k_base"
+
+	// Every message is wrapped in tokens marking its start, role and end,
+	// and every reply is primed with the start of an assistant message
+	tokensPerMessage = 3
+	tokensPerReply   = 3
+)
+
+// encodingSpec describes an encoding as tiktoken defines it: the file of its merge ranks,
+// bundled with tiktoken-go-loader, the pattern splitting text into words and its special tokens
+type encodingSpec struct {
+	file    string
+	pattern string
+	special map[string]int
+}
+
+var encodingSpecs = map[string]encodingSpec{
+	encodingCL100K: {
+		file:    "cl100k_base.tiktoken",
+		pattern: `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+`,
+		special: map[string]int{
+			tiktoken.ENDOFTEXT:   100257,
+			tiktoken.FIM_PREFIX:  100258,
+			tiktoken.FIM_MIDDLE:  100259,
+			tiktoken.FIM_SUFFIX:  100260,
+			tiktoken.ENDOFPROMPT: 100276,
+		},
+	},
+	encodingO200K: {
+		file: "o200k_base.tiktoken",
+		pattern: strings.Join([]string{
+			`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?`,
+			`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?`,
+			`\p{N}{1,3}`,
+			` ?[^\s\p{L}\p{N}]+[\r\n/]*`,
+			`\s*[\r\n]+`,
+			`\s+(?!\S)`,
+			`\s+`,
+		}, "|"),
+		special: map[string]int{
+			tiktoken.ENDOFTEXT:   199999,
+			tiktoken.ENDOFPROMPT: 200018,
+		},
+	},
+}
+
+var (
+	encodingsMu sync.Mutex
+	encodings   = make(map[string]*tiktoken.Tiktoken)
+)
+
+// encodingFor returns the name of the encoding used by a model.
+// GPT-4, GPT-3.5 and the embedding models use cl100k_base; newer models use o200k_base.
+func encodingFor(model string) string {
+	switch {
+	case model == "gpt-4", strings.HasPrefix(model, "gpt-4-"),
+		strings.HasPrefix(model, "gpt-3.5"), strings.HasPrefix(model, "text-embedding-"):
+		return encodingCL100K
+	default:
+		return encodingO200K
+	}
+}
+
+// encoding returns the tokenizer for a model, loading it on first use
+func encoding(model string) (*tiktoken.Tiktoken, error) {
+	name := encodingFor(model)
+
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+
+	if enc, ok := encodings[name]; ok {
+		return enc, nil
+	}
+
+	enc, err := newEncoding(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s encoding: %w", name, err)
+	}
+	encodings[name] = enc
+	return enc, nil
+}
+
+// newEncoding builds a tokenizer from the encodings bundled with tiktoken-go-loader, rather
+// than through tiktoken.GetEncoding, which would need its package-wide loader replaced to
+// avoid downloading them
+func newEncoding(name string) (*tiktoken.Tiktoken, error) {
+	spec := encodingSpecs[name]
+	ranks, err := tiktoken_loader.NewOfflineLoader().LoadTiktokenBpe(spec.file)
+	if err != nil {
+		return nil, err
+	}
+
+	bpe, err := tiktoken.NewCoreBPE(ranks, spec.special, spec.pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	specialSet := make(map[string]any, len(spec.special))
+	for token := range spec.special {
+		specialSet[token] = true
+	}
+	return tiktoken.NewTiktoken(bpe, &tiktoken.Encoding{
+		Name:           name,
+		PatStr:         spec.pattern,
+		MergeableRanks: ranks,
+		SpecialTokens:  spec.special,
+	}, specialSet), nil
+}
+
+// CountTokens counts the input tokens of a request with the model's tokenizer, without calling
+// the API. Messages are counted exactly; tool definitions are approximated from their JSON, and
+// images and documents are not counted.
+func (p *Provider) CountTokens(ctx context.Context, config *ai.Config) (int, error) {
+	enc, err := encoding(config.Model)
+	if err != nil {
+		return 0, err
+	}
+
+	count := func(text string) int {
+		return len(enc.EncodeOrdinary(text))
+	}
+
+	tokens := tokensPerReply
+	for _, msg := range config.Messages {
+		tokens += tokensPerMessage + count(string(msg.Role)) + count(msg.Content)
+		for _, part := range msg.Parts {
+			if part.Type == ai.PartTypeText {
+				tokens += count(part.Text)
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			tokens += count(call.Name) + count(string(call.Arguments))
+		}
+	}
+	for _, tool := range config.Tools {
+		tokens += count(tool.Name) + count(tool.Description) + count(string(tool.Parameters))
+	}
+
+	return tokens, nil
+}
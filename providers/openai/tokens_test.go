@@ -0,0 +1,66 @@
+package openai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gnfisher/go-ai-sdk"
+)
+
+func TestEncodingFor(t *testing.T) {
+	tests := map[string]string{
+		"gpt-4":             encodingCL100K,
+		"gpt-4-turbo":       encodingCL100K,
+		"gpt-3.5-turbo":     encodingCL100K,
+		"gpt-4o":            encodingO200K,
+		"gpt-4o-2024-08-06": encodingO200K,
+		"gpt-4.1-mini":      encodingO200K,
+		"o3":                encodingO200K,
+	}
+
+	for model, expected := range tests {
+		if got := encodingFor(model); got != expected {
+			t.Errorf("%s: expected %s, got %s", model, expected, got)
+		}
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	provider := New()
+
+	// Token counts from OpenAI's reference tokenizer
+	tests := []struct {
+		model    string
+		messages []ai.Message
+		expected int
+	}{
+		{
+			model:    "gpt-4o",
+			messages: []ai.Message{ai.UserMessage("Hello, world!")},
+			expected: 3 + 3 + 1 + 4,
+		},
+		{
+			model:    "gpt-4",
+			messages: []ai.Message{ai.UserMessage("Hello, world!")},
+			expected: 3 + 3 + 1 + 4,
+		},
+		{
+			model: "gpt-4o",
+			messages: []ai.Message{
+				ai.SystemMessage("You are a helpful assistant."),
+				ai.UserMessage("Tell me a joke."),
+			},
+			expected: 3 + (3 + 1 + 6) + (3 + 1 + 5),
+		},
+	}
+
+	for _, tt := range tests {
+		count, err := provider.CountTokens(context.Background(), &ai.Config{Model: tt.model, Messages: tt.messages})
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.model, err)
+		}
+		if count != tt.expected {
+			t.Errorf("%s: expected %d tokens, got %d", tt.model, tt.expected, count)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimit is a budget of requests and tokens per minute. Zero values are unlimited.
+type RateLimit struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// RateLimitStatus is the state of a provider's rate limits, as reported in response headers.
+// The request and token limits are each known only when their Limit is positive.
+type RateLimitStatus struct {
+	RequestsLimit     int
+	RequestsRemaining int
+	RequestsReset     time.Time
+
+	TokensLimit     int
+	TokensRemaining int
+	TokensReset     time.Time
+}
+
+// WithRateLimitObserver sets a function called with the rate limits reported in each response
+func WithRateLimitObserver(observer func(RateLimitStatus)) Option {
+	return func(c *Config) {
+		c.RateLimitObserver = observer
+	}
+}
+
+// RateLimiter keeps requests to providers within per-minute request and token budgets.
+// Wrap each provider with the limiter before registering it with a client:
+//
+//	limiter := ai.NewRateLimiter(ai.WithRateLimit(ai.ProviderOpenAI, "gpt-4o", ai.RateLimit{
+//		RequestsPerMinute: 500,
+//		TokensPerMinute:   30000,
+//	}))
+//	client.RegisterProvider(ai.ProviderOpenAI, limiter.Wrap(openai.New()))
+//
+// Requests block until the budget for their provider and model has capacity.
+// The budgets adapt to the limits providers report in their response headers,
+// which account for other clients sharing the same API key.
+type RateLimiter struct {
+	mu           sync.Mutex
+	limits       map[rateLimitKey]RateLimit
+	defaultLimit RateLimit
+	buckets      map[rateLimitKey]*rateBuckets
+	estimate     func(config *Config) int
+	now          func() time.Time
+}
+
+// RateLimiterOption is a function that modifies a RateLimiter
+type RateLimiterOption func(*RateLimiter)
+
+// WithRateLimit sets the budget for a provider's model. An empty model sets
+// a budget shared by all of the provider's models that have no budget of their own.
+func WithRateLimit(provider Provider, model string, limit RateLimit) RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.limits[rateLimitKey{provider, model}] = limit
+	}
+}
+
+// WithDefaultRateLimit sets the budget for each provider and model without one of its own
+func WithDefaultRateLimit(limit RateLimit) RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.defaultLimit = limit
+	}
+}
+
+// WithTokenEstimator sets the function that estimates the tokens a request will use
+// before it is sent. The estimate is corrected once the provider reports the usage.
+func WithTokenEstimator(estimate func(config *Config) int) RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.estimate = estimate
+	}
+}
+
+// NewRateLimiter creates a new rate limiter
+func NewRateLimiter(options ...RateLimiterOption) *RateLimiter {
+	l := &RateLimiter{
+		limits:   make(map[rateLimitKey]RateLimit),
+		buckets:  make(map[rateLimitKey]*rateBuckets),
+		estimate: estimateTokens,
+		now:      time.Now,
+	}
+
+	for _, opt := range options {
+		opt(l)
+	}
+
+	return l
+}
+
+// Wrap returns a provider that sends requests to provider within the limiter's budgets.
+// A limiter can wrap several providers.
+func (l *RateLimiter) Wrap(provider LLMProvider) LLMProvider {
+	return &rateLimitedProvider{limiter: l, provider: provider}
+}
+
+type rateLimitKey struct {
+	provider Provider
+	model    string
+}
+
+// rateBuckets holds the request and token budgets for a provider and model
+type rateBuckets struct {
+	requests rateBucket
+	tokens   rateBucket
+}
+
+// rateBucket is a token bucket refilled at capacity per minute.
+// A capacity of zero is unlimited unless the provider reported the limit exhausted.
+type rateBucket struct {
+	configured bool
+	capacity   float64
+	available  float64
+	updated    time.Time
+
+	// blockedUntil is when an exhausted limit reported by the provider resets
+	blockedUntil time.Time
+}
+
+func newRateBucket(perMinute int, now time.Time) rateBucket {
+	return rateBucket{
+		configured: perMinute > 0,
+		capacity:   float64(perMinute),
+		available:  float64(perMinute),
+		updated:    now,
+	}
+}
+
+// refill adds the capacity accrued since the bucket was last updated
+func (b *rateBucket) refill(now time.Time) {
+	if b.capacity > 0 && now.After(b.updated) {
+		b.available += now.Sub(b.updated).Minutes() * b.capacity
+		if b.available > b.capacity {
+			b.available = b.capacity
+		}
+	}
+	b.updated = now
+}
+
+// wait returns how long until the bucket can take n. Requests larger than the
+// capacity wait for a full bucket and leave it in debt.
+func (b *rateBucket) wait(now time.Time, n float64) time.Duration {
+	var wait time.Duration
+	if now.Before(b.blockedUntil) {
+		wait = b.blockedUntil.Sub(now)
+	}
+	if b.capacity <= 0 {
+		return wait
+	}
+
+	if n > b.capacity {
+		n = b.capacity
+	}
+	if b.available < n {
+		refill := time.Duration((n - b.available) / b.capacity * float64(time.Minute))
+		if refill > wait {
+			wait = refill
+		}
+	}
+	return wait
+}
+
+// take removes n from the bucket, or returns it when n is negative
+func (b *rateBucket) take(n float64) {
+	if b.capacity <= 0 {
+		return
+	}
+	b.available -= n
+	if b.available > b.capacity {
+		b.available = b.capacity
+	}
+}
+
+// observe adapts the bucket to a limit reported by the provider
+func (b *rateBucket) observe(now time.Time, limit, remaining int, reset time.Time) {
+	if limit <= 0 {
+		return
+	}
+
+	switch {
+	case !b.configured && b.capacity == 0:
+		// Learn the limit from the first response
+		b.capacity = float64(limit)
+		b.available = float64(remaining)
+	case !b.configured || float64(limit) < b.capacity:
+		b.capacity = float64(limit)
+	}
+	if float64(remaining) < b.available {
+		b.available = float64(remaining)
+	}
+	if remaining <= 0 && reset.After(now) {
+		b.blockedUntil = reset
+	}
+}
+
+// bucketsFor returns the budgets for a request. The caller must hold l.mu.
+func (l *RateLimiter) bucketsFor(config *Config, now time.Time) *rateBuckets {
+	key := rateLimitKey{config.Provider, config.Model}
+	limit, ok := l.limits[key]
+	if !ok {
+		shared := rateLimitKey{config.Provider, ""}
+		if limit, ok = l.limits[shared]; ok {
+			key = shared
+		} else {
+			limit = l.defaultLimit
+		}
+	}
+
+	buckets, ok := l.buckets[key]
+	if !ok {
+		buckets = &rateBuckets{
+			requests: newRateBucket(limit.RequestsPerMinute, now),
+			tokens:   newRateBucket(limit.TokensPerMinute, now),
+		}
+		l.buckets[key] = buckets
+	}
+	return buckets
+}
+
+// acquire blocks until the budgets have capacity for a request using the given number of tokens
+func (l *RateLimiter) acquire(ctx context.Context, config *Config, tokens int) error {
+	for {
+		l.mu.Lock()
+		now := l.now()
+		buckets := l.bucketsFor(config, now)
+		buckets.requests.refill(now)
+		buckets.tokens.refill(now)
+
+		wait := buckets.requests.wait(now, 1)
+		if tokenWait := buckets.tokens.wait(now, float64(tokens)); tokenWait > wait {
+			wait = tokenWait
+		}
+		if wait <= 0 {
+			buckets.requests.take(1)
+			buckets.tokens.take(float64(tokens))
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		// Give up now if the context will expire before there is capacity
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return fmt.Errorf("%w: %s %s has no capacity before the context deadline", ErrRateLimited, config.Provider, config.Model)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// settle corrects the tokens taken for a request once its usage is known
+func (l *RateLimiter) settle(config *Config, estimated int, usage Usage) {
+	actual := usage.TotalTokens()
+	if actual == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	buckets := l.bucketsFor(config, now)
+	buckets.tokens.refill(now)
+	buckets.tokens.take(float64(actual - estimated))
+}
+
+// observe adapts the budgets to the limits reported by the provider
+func (l *RateLimiter) observe(config *Config, status RateLimitStatus) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	buckets := l.bucketsFor(config, now)
+	buckets.requests.refill(now)
+	buckets.tokens.refill(now)
+	buckets.requests.observe(now, status.RequestsLimit, status.RequestsRemaining, status.RequestsReset)
+	buckets.tokens.observe(now, status.TokensLimit, status.TokensRemaining, status.TokensReset)
+}
+
+// estimateTokens roughly estimates the tokens a request will use: its input plus the maximum output
+func estimateTokens(config *Config) int {
+	return EstimateTokens(config) + config.MaxTokens
+}
+
+// rateLimitedProvider sends requests to a provider within a RateLimiter's budgets
+type rateLimitedProvider struct {
+	limiter  *RateLimiter
+	provider LLMProvider
+}
+
+// begin waits for capacity and returns the config to send, which reports
+// rate limits back to the limiter, and the estimated tokens taken
+func (p *rateLimitedProvider) begin(ctx context.Context, config *Config) (*Config, int, error) {
+	estimated := p.limiter.estimate(config)
+	if err := p.limiter.acquire(ctx, config, estimated); err != nil {
+		return nil, 0, err
+	}
+
+	limited := *config
+	limited.RateLimitObserver = func(status RateLimitStatus) {
+		p.limiter.observe(config, status)
+		if config.RateLimitObserver != nil {
+			config.RateLimitObserver(status)
+		}
+	}
+	return &limited, estimated, nil
+}
+
+// CountTokens counts tokens with the wrapped provider, without waiting for capacity
+func (p *rateLimitedProvider) CountTokens(ctx context.Context, config *Config) (int, error) {
+	return countTokens(ctx, p.provider, config)
+}
+
+func (p *rateLimitedProvider) GetText(ctx context.Context, config *Config) (string, error) {
+	limited, _, err := p.begin(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	return p.provider.GetText(ctx, limited)
+}
+
+func (p *rateLimitedProvider) GetObject(ctx context.Context, config *Config, target interface{}) error {
+	limited, _, err := p.begin(ctx, config)
+	if err != nil {
+		return err
+	}
+	return p.provider.GetObject(ctx, limited, target)
+}
+
+func (p *rateLimitedProvider) GenerateText(ctx context.Context, config *Config) (*Result, error) {
+	limited, estimated, err := p.begin(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := generateText(ctx, p.provider, limited)
+	if result != nil {
+		p.limiter.settle(config, estimated, result.Usage)
+	}
+	return result, err
+}
+
+func (p *rateLimitedProvider) GenerateObject(ctx context.Context, config *Config, target interface{}) (*Result, error) {
+	limited, estimated, err := p.begin(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := getObject(ctx, p.provider, limited, target)
+	if result != nil {
+		p.limiter.settle(config, estimated, result.Usage)
+	}
+	return result, err
+}
+
+func (p *rateLimitedProvider) StreamText(ctx context.Context, config *Config) (*Stream, error) {
+	streamer, ok := p.provider.(StreamingProvider)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStreamingNotSupported, config.Provider)
+	}
+
+	limited, estimated, err := p.begin(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := streamer.StreamText(ctx, limited)
+	if err != nil {
+		return nil, err
+	}
+	return p.settleStream(stream, config, estimated), nil
+}
+
+func (p *rateLimitedProvider) StreamObject(ctx context.Context, config *Config, target interface{}) (*Stream, error) {
+	streamer, ok := p.provider.(ObjectStreamer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStreamingNotSupported, config.Provider)
+	}
+
+	limited, estimated, err := p.begin(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := streamer.StreamObject(ctx, limited, target)
+	if err != nil {
+		return nil, err
+	}
+	return p.settleStream(stream, config, estimated), nil
+}
+
+// settleStream corrects the tokens taken for a stream once it finishes
+func (p *rateLimitedProvider) settleStream(stream *Stream, config *Config, estimated int) *Stream {
+	stream.OnFinish(func(result *Result, err error) {
+		p.limiter.settle(config, estimated, result.Usage)
+	})
+	return stream
+}
@@ -0,0 +1,154 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateBucket(t *testing.T) {
+	now := time.Now()
+	bucket := newRateBucket(60, now)
+
+	if wait := bucket.wait(now, 60); wait != 0 {
+		t.Errorf("Expected a full bucket to take its capacity, got wait %v", wait)
+	}
+	bucket.take(60)
+
+	// The bucket refills at one per second
+	if wait := bucket.wait(now, 2); wait != 2*time.Second {
+		t.Errorf("Expected to wait 2s, got %v", wait)
+	}
+	bucket.refill(now.Add(time.Second))
+	if wait := bucket.wait(now.Add(time.Second), 1); wait != 0 {
+		t.Errorf("Expected capacity after 1s, got wait %v", wait)
+	}
+
+	// Requests larger than the capacity wait for a full bucket
+	if wait := bucket.wait(now.Add(time.Second), 1000); wait != 59*time.Second {
+		t.Errorf("Expected to wait 59s, got %v", wait)
+	}
+
+	// Unconfigured buckets are unlimited until a limit is reported
+	unlimited := newRateBucket(0, now)
+	if wait := unlimited.wait(now, 1e9); wait != 0 {
+		t.Errorf("Expected no wait, got %v", wait)
+	}
+	unlimited.observe(now, 100, 0, now.Add(5*time.Second))
+	if unlimited.capacity != 100 {
+		t.Errorf("Expected the reported limit to be learned, got %v", unlimited.capacity)
+	}
+	if wait := unlimited.wait(now, 1); wait != 5*time.Second {
+		t.Errorf("Expected to wait for the reported reset, got %v", wait)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	var calls int
+	provider := &MockProvider{
+		GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+			calls++
+			return "ok", nil
+		},
+	}
+
+	limiter := NewRateLimiter(
+		WithRateLimit(ProviderOpenAI, "gpt-4o", RateLimit{RequestsPerMinute: 2}),
+		WithDefaultRateLimit(RateLimit{RequestsPerMinute: 1000}),
+	)
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("gpt-4o"))
+	client.RegisterProvider(ProviderOpenAI, limiter.Wrap(provider))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetText(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	// The next request would wait 30s, longer than the context allows
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := client.GetText(ctx)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls)
+	}
+
+	// Other models have their own budget
+	if _, err := client.GetText(ctx, WithModel("gpt-4o-mini")); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	provider := &MockProvider{
+		GetTextFunc: func(ctx context.Context, config *Config) (string, error) {
+			return "ok", nil
+		},
+	}
+
+	limiter := NewRateLimiter(WithDefaultRateLimit(RateLimit{RequestsPerMinute: 1}))
+	wrapped := limiter.Wrap(provider)
+	config := &Config{Provider: ProviderOpenAI, Model: "gpt-4o"}
+
+	if _, err := wrapped.GetText(context.Background(), config); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	if _, err := wrapped.GetText(ctx, config); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRateLimiterTokens(t *testing.T) {
+	var observed []RateLimitStatus
+	provider := &MockStreamer{
+		StreamTextFunc: func(ctx context.Context, config *Config) (*Stream, error) {
+			config.RateLimitObserver(RateLimitStatus{TokensLimit: 10000, TokensRemaining: 9000})
+			return NewStream(&sliceReader{deltas: []Delta{
+				{Text: "Hello"},
+				{Usage: &Usage{InputTokens: 50, OutputTokens: 50}},
+			}}), nil
+		},
+	}
+
+	limiter := NewRateLimiter(
+		WithDefaultRateLimit(RateLimit{TokensPerMinute: 20000}),
+		WithTokenEstimator(func(config *Config) int { return 1000 }),
+	)
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("gpt-4o"))
+	client.RegisterProvider(ProviderOpenAI, limiter.Wrap(provider))
+
+	stream, err := client.StreamText(context.Background(), WithRateLimitObserver(func(status RateLimitStatus) {
+		observed = append(observed, status)
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for stream.Next() {
+	}
+
+	buckets := limiter.buckets[rateLimitKey{ProviderOpenAI, "gpt-4o"}]
+
+	// The reported limit lowers the budget, and the estimate of 1000 tokens
+	// is corrected to the 100 used once the stream finishes
+	if buckets.tokens.capacity != 10000 {
+		t.Errorf("Expected capacity 10000, got %v", buckets.tokens.capacity)
+	}
+	if available := buckets.tokens.available; available < 9900 || available > 9901 {
+		t.Errorf("Expected about 9900 tokens available, got %v", available)
+	}
+	if len(observed) != 1 {
+		t.Errorf("Expected the request's observer to be called, got %d calls", len(observed))
+	}
+}
@@ -0,0 +1,77 @@
+package ai
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	// DefaultRetryBaseDelay is the delay before the first retry when a policy does not set one
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+
+	// DefaultRetryMaxDelay caps the delay between retries when a policy does not set a limit
+	DefaultRetryMaxDelay = 30 * time.Second
+)
+
+// RetryPolicy controls how providers retry requests that fail with a transient error,
+// such as a rate limit, an overloaded server or a dropped connection.
+// The zero value makes a single attempt.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, doubled for each retry after it
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between retries, including delays asked for by the server
+	MaxDelay time.Duration
+
+	// Jitter is the fraction of each delay, from 0 to 1, that is randomized
+	// so that clients retrying together spread out
+	Jitter float64
+}
+
+// DefaultRetryPolicy is a reasonable policy for batch workloads
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   DefaultRetryBaseDelay,
+	MaxDelay:    DefaultRetryMaxDelay,
+	Jitter:      0.2,
+}
+
+// Delay returns how long to wait before the given retry, starting from 1 for the first retry
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = DefaultRetryBaseDelay
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+
+	delay := base
+	for i := 1; i < retry && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay -= time.Duration(float64(delay) * jitter * rand.Float64())
+	}
+
+	return delay
+}
+
+// WithRetry sets the retry policy for the request, overriding the provider's policy
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Config) {
+		c.Retry = &policy
+	}
+}
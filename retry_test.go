@@ -0,0 +1,47 @@
+package ai
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, want := range expected {
+		if got := policy.Delay(i + 1); got != want {
+			t.Errorf("Delay(%d) = %v, want %v", i+1, got, want)
+		}
+	}
+
+	// Jitter only ever shortens the delay
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.Delay(2); got < 100*time.Millisecond || got > 200*time.Millisecond {
+			t.Fatalf("Delay with jitter = %v, want between 100ms and 200ms", got)
+		}
+	}
+
+	// The zero value uses the defaults
+	if got := (RetryPolicy{}).Delay(1); got != DefaultRetryBaseDelay {
+		t.Errorf("Expected default base delay, got %v", got)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	client := NewClient(WithRetry(DefaultRetryPolicy))
+
+	config := client.mergeConfig()
+	if config.Retry == nil || config.Retry.MaxAttempts != DefaultRetryPolicy.MaxAttempts {
+		t.Fatalf("Expected default retry policy, got %+v", config.Retry)
+	}
+
+	config = client.mergeConfig(WithRetry(RetryPolicy{MaxAttempts: 1}))
+	if config.Retry.MaxAttempts != 1 {
+		t.Errorf("Expected per-call retry policy, got %+v", config.Retry)
+	}
+	if client.defaults.Retry.MaxAttempts != DefaultRetryPolicy.MaxAttempts {
+		t.Errorf("Expected defaults to be unchanged")
+	}
+}
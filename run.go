@@ -0,0 +1,142 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultMaxSteps is the number of model calls Run makes when no limit is configured
+const DefaultMaxSteps = 10
+
+// ErrMaxStepsExceeded is returned when Run reaches its step limit before the model gives a final answer
+var ErrMaxStepsExceeded = errors.New("maximum number of steps exceeded")
+
+// ToolHandler executes a tool call and returns the result to send back to the model.
+// A returned error is reported to the model as the tool result so it can recover.
+type ToolHandler func(ctx context.Context, call ToolCall) (string, error)
+
+// RunResult holds the outcome of a multi-step tool run
+type RunResult struct {
+	// Text is the model's final answer
+	Text string
+
+	// Messages is the full transcript, starting with the request messages
+	Messages []Message
+
+	// Steps is the number of model calls that were made
+	Steps int
+}
+
+// WithToolHandler registers a tool together with the handler that executes it during Run
+func WithToolHandler(tool Tool, handler ToolHandler) Option {
+	return func(c *Config) {
+		if c.ToolHandlers == nil {
+			c.ToolHandlers = make(map[string]ToolHandler)
+		}
+		c.Tools = append(c.Tools, tool)
+		c.ToolHandlers[tool.Name] = handler
+	}
+}
+
+// WithMaxSteps sets the maximum number of model calls Run makes
+func WithMaxSteps(maxSteps int) Option {
+	return func(c *Config) {
+		c.MaxSteps = maxSteps
+	}
+}
+
+// WithParallelToolCalls sets whether Run executes the tool calls of a single step concurrently
+func WithParallelToolCalls(parallel bool) Option {
+	return func(c *Config) {
+		c.ParallelToolCalls = parallel
+	}
+}
+
+// Run calls the model, executes any requested tool calls with the registered handlers
+// and feeds the results back until the model returns a final answer or MaxSteps is reached.
+// The transcript so far is returned along with any error.
+func (c *Client) Run(ctx context.Context, options ...Option) (*RunResult, error) {
+	config := c.mergeConfig(options...)
+
+	provider, err := c.provider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := provider.(Generator); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrToolsNotSupported, config.Provider)
+	}
+
+	maxSteps := config.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+
+	run := &RunResult{
+		Messages: append([]Message(nil), config.Messages...),
+	}
+
+	for run.Steps < maxSteps {
+		stepConfig := *config
+		stepConfig.Messages = run.Messages
+
+		resp, err := c.handle(ctx, &Request{Kind: RequestGenerate, Config: &stepConfig})
+		if err != nil {
+			return run, err
+		}
+		result := resp.Result
+
+		run.Steps++
+		run.Messages = append(run.Messages, result.Message())
+
+		if len(result.ToolCalls) == 0 {
+			run.Text = result.Text
+			return run, nil
+		}
+
+		run.Messages = append(run.Messages, executeToolCalls(ctx, config, result.ToolCalls)...)
+	}
+
+	return run, ErrMaxStepsExceeded
+}
+
+// executeToolCalls runs the handlers for the given calls and returns their results as tool messages
+func executeToolCalls(ctx context.Context, config *Config, calls []ToolCall) []Message {
+	results := make([]Message, len(calls))
+
+	if !config.ParallelToolCalls {
+		for i, call := range calls {
+			results[i] = executeToolCall(ctx, config, call)
+		}
+		return results
+	}
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call ToolCall) {
+			defer wg.Done()
+			results[i] = executeToolCall(ctx, config, call)
+		}(i, call)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// executeToolCall runs the handler for a single call, reporting failures back to the model
+func executeToolCall(ctx context.Context, config *Config, call ToolCall) Message {
+	handler, ok := config.ToolHandlers[call.Name]
+	if !ok {
+		return ToolResultMessage(call.ID, fmt.Sprintf("Error: unknown tool %q", call.Name))
+	}
+
+	output, err := handler(ctx, call)
+	if err != nil {
+		return ToolResultMessage(call.ID, fmt.Sprintf("Error: %v", err))
+	}
+
+	return ToolResultMessage(call.ID, output)
+}
@@ -0,0 +1,162 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	weatherTool := Tool{Name: "get_weather", Description: "Get the weather for a city"}
+
+	mockGenerator := &MockGenerator{
+		GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+			last := config.Messages[len(config.Messages)-1]
+			switch last.Role {
+			case RoleUser:
+				return &Result{
+					ToolCalls: []ToolCall{
+						{ID: "call_1", Name: "get_weather", Arguments: json.RawMessage(`{"city":"Paris"}`)},
+						{ID: "call_2", Name: "get_weather", Arguments: json.RawMessage(`{"city":"London"}`)},
+					},
+				}, nil
+			case RoleTool:
+				return &Result{Text: "Paris is sunny and London is rainy."}, nil
+			}
+			return nil, errors.New("unexpected message")
+		},
+	}
+
+	var calls int32
+	handler := func(ctx context.Context, call ToolCall) (string, error) {
+		atomic.AddInt32(&calls, 1)
+
+		var args struct {
+			City string `json:"city"`
+		}
+		if err := json.Unmarshal(call.Arguments, &args); err != nil {
+			return "", err
+		}
+		if args.City == "Paris" {
+			return "Sunny", nil
+		}
+		return "Rainy", nil
+	}
+
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, mockGenerator)
+
+	for _, parallel := range []bool{false, true} {
+		atomic.StoreInt32(&calls, 0)
+
+		result, err := client.Run(context.Background(),
+			WithMessages(UserMessage("What's the weather in Paris and London?")),
+			WithToolHandler(weatherTool, handler),
+			WithParallelToolCalls(parallel),
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if result.Text != "Paris is sunny and London is rainy." {
+			t.Errorf("Unexpected final text: %s", result.Text)
+		}
+
+		if result.Steps != 2 {
+			t.Errorf("Expected 2 steps, got %d", result.Steps)
+		}
+
+		if atomic.LoadInt32(&calls) != 2 {
+			t.Errorf("Expected 2 handler calls, got %d", calls)
+		}
+
+		// user, assistant tool calls, two tool results, final answer
+		if len(result.Messages) != 5 {
+			t.Fatalf("Expected 5 messages in transcript, got %d", len(result.Messages))
+		}
+
+		if result.Messages[2].ToolCallID != "call_1" || result.Messages[2].Content != "Sunny" {
+			t.Errorf("Unexpected tool result: %+v", result.Messages[2])
+		}
+		if result.Messages[3].ToolCallID != "call_2" || result.Messages[3].Content != "Rainy" {
+			t.Errorf("Unexpected tool result: %+v", result.Messages[3])
+		}
+	}
+}
+
+func TestRunMaxSteps(t *testing.T) {
+	mockGenerator := &MockGenerator{
+		GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+			return &Result{
+				ToolCalls: []ToolCall{{ID: "call", Name: "loop", Arguments: json.RawMessage(`{}`)}},
+			}, nil
+		},
+	}
+
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, mockGenerator)
+
+	result, err := client.Run(context.Background(),
+		WithMessages(UserMessage("Loop forever")),
+		WithToolHandler(Tool{Name: "loop"}, func(ctx context.Context, call ToolCall) (string, error) {
+			return "again", nil
+		}),
+		WithMaxSteps(3),
+	)
+	if !errors.Is(err, ErrMaxStepsExceeded) {
+		t.Errorf("Expected ErrMaxStepsExceeded, got %v", err)
+	}
+
+	if result == nil || result.Steps != 3 {
+		t.Errorf("Expected 3 steps in partial result, got %+v", result)
+	}
+}
+
+func TestRunToolErrors(t *testing.T) {
+	mockGenerator := &MockGenerator{
+		GenerateTextFunc: func(ctx context.Context, config *Config) (*Result, error) {
+			if config.Messages[len(config.Messages)-1].Role == RoleTool {
+				return &Result{Text: "done"}, nil
+			}
+			return &Result{
+				ToolCalls: []ToolCall{
+					{ID: "call_1", Name: "broken"},
+					{ID: "call_2", Name: "missing"},
+				},
+			}, nil
+		},
+	}
+
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, mockGenerator)
+
+	result, err := client.Run(context.Background(),
+		WithMessages(UserMessage("Try the tools")),
+		WithToolHandler(Tool{Name: "broken"}, func(ctx context.Context, call ToolCall) (string, error) {
+			return "", errors.New("boom")
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.Contains(result.Messages[2].Content, "boom") {
+		t.Errorf("Expected handler error to be reported, got %q", result.Messages[2].Content)
+	}
+	if !strings.Contains(result.Messages[3].Content, "unknown tool") {
+		t.Errorf("Expected unknown tool to be reported, got %q", result.Messages[3].Content)
+	}
+}
+
+func TestRunRequiresGenerator(t *testing.T) {
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, &MockProvider{})
+
+	_, err := client.Run(context.Background(), WithMessages(UserMessage("Hello")))
+	if !errors.Is(err, ErrToolsNotSupported) {
+		t.Errorf("Expected ErrToolsNotSupported, got %v", err)
+	}
+}
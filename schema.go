@@ -0,0 +1,500 @@
+package ai
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema describing a JSON value
+type Schema struct {
+	Type        string        `json:"type,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Format      string        `json:"format,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+
+	// Object keywords
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"` // *Schema or bool
+
+	// Array keywords
+	Items *Schema `json:"items,omitempty"`
+
+	// AnyOf matches values that match any of the given schemas
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+}
+
+// JSONSchemaer is implemented by types that describe their own JSON Schema
+type JSONSchemaer interface {
+	JSONSchema() *Schema
+}
+
+var (
+	timeType         = reflect.TypeOf(time.Time{})
+	rawMessageType   = reflect.TypeOf(json.RawMessage{})
+	jsonSchemaerType = reflect.TypeOf((*JSONSchemaer)(nil)).Elem()
+	marshalerType    = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalType  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SchemaFor generates a JSON Schema for the JSON encoding of v's type.
+//
+// Struct fields follow encoding/json naming rules; fields without omitempty are required.
+// Required pointer, slice and map fields also accept null, since that is how encoding/json
+// writes nil values.
+// Fields can be described with a jsonschema tag holding comma-separated options:
+//
+//	Mood string `json:"mood" jsonschema:"description=How the person feels,enum=happy,enum=sad"`
+//
+// Supported options are description, format, enum (repeatable) and required.
+// Commas inside values are escaped with a backslash.
+//
+// Recursive types, such as trees, are described down to the first repetition of a type,
+// which accepts any value.
+func SchemaFor(v interface{}) (*Schema, error) {
+	if v == nil {
+		return &Schema{}, nil
+	}
+	return SchemaForType(reflect.TypeOf(v))
+}
+
+// SchemaForType generates a JSON Schema for the JSON encoding of values of type t
+func SchemaForType(t reflect.Type) (*Schema, error) {
+	g := &schemaGenerator{visiting: make(map[reflect.Type]bool)}
+	return g.schema(t)
+}
+
+// schemaGenerator tracks the struct types being walked to stop at recursion
+type schemaGenerator struct {
+	visiting map[reflect.Type]bool
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		if t.Implements(jsonSchemaerType) {
+			break
+		}
+		t = t.Elem()
+	}
+
+	if t.Implements(jsonSchemaerType) {
+		return reflect.Zero(t).Interface().(JSONSchemaer).JSONSchema(), nil
+	}
+	if reflect.PointerTo(t).Implements(jsonSchemaerType) {
+		return reflect.New(t).Interface().(JSONSchemaer).JSONSchema(), nil
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	case rawMessageType:
+		return &Schema{}, nil
+	}
+
+	// Types with custom JSON encodings can produce anything
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		return &Schema{}, nil
+	}
+	if t.Implements(textMarshalType) || reflect.PointerTo(t).Implements(textMarshalType) {
+		return &Schema{Type: "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+
+	case reflect.Interface:
+		return &Schema{}, nil
+
+	case reflect.Slice, reflect.Array:
+		// []byte is encoded as a base64 string
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			if !t.Key().Implements(textMarshalType) {
+				return nil, fmt.Errorf("cannot generate schema for map key type %s", t.Key())
+			}
+		}
+
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+
+	case reflect.Struct:
+		return g.structSchema(t)
+	}
+
+	return nil, fmt.Errorf("cannot generate schema for type %s", t)
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) (*Schema, error) {
+	// A type nested in itself accepts any value, since the schema cannot repeat forever
+	if g.visiting[t] {
+		return &Schema{}, nil
+	}
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	if err := g.addFields(schema, t); err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// addFields adds the fields of struct type t to schema. Fields of embedded structs
+// are promoted after the direct fields, so direct fields take precedence.
+func (g *schemaGenerator) addFields(schema *Schema, t reflect.Type) error {
+	var embedded []reflect.Type
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				embedded = append(embedded, fieldType)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if _, exists := schema.Properties[name]; exists {
+			continue
+		}
+
+		property, err := g.schema(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		// The string option encodes scalars as JSON strings
+		if hasOption(opts, "string") && property.Type != "" && property.Type != "object" && property.Type != "array" {
+			property = &Schema{Type: "string"}
+		}
+
+		required := !hasOption(opts, "omitempty")
+
+		if jsonschema, ok := field.Tag.Lookup("jsonschema"); ok {
+			property, required, err = applySchemaTag(property, required, jsonschema, field.Type)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+
+		if required {
+			// encoding/json writes nil pointers, slices and maps as null
+			if nullableKinds[field.Type.Kind()] {
+				property = nullable(property)
+			}
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+
+	for _, embeddedType := range embedded {
+		if g.visiting[embeddedType] {
+			continue
+		}
+		g.visiting[embeddedType] = true
+		err := g.addFields(schema, embeddedType)
+		delete(g.visiting, embeddedType)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nullableKinds are the kinds of Go values that can be encoded as JSON null
+var nullableKinds = map[reflect.Kind]bool{
+	reflect.Pointer: true, reflect.Slice: true, reflect.Map: true, reflect.Interface: true,
+}
+
+// nullable returns a schema that also accepts null. Schemas that accept any value are
+// returned unchanged.
+func nullable(schema *Schema) *Schema {
+	if schema.Type == "" && len(schema.AnyOf) == 0 {
+		return schema
+	}
+	return &Schema{AnyOf: []*Schema{schema, {Type: "null"}}}
+}
+
+// hasOption reports whether a comma-separated json tag option list contains option
+func hasOption(opts string, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// applySchemaTag applies the options of a jsonschema struct tag to a property schema
+func applySchemaTag(property *Schema, required bool, tag string, fieldType reflect.Type) (*Schema, bool, error) {
+	// Copy the schema so that schemas returned by JSONSchema methods are not modified
+	tagged := *property
+
+	for _, option := range splitEscaped(tag) {
+		key, value, _ := strings.Cut(option, "=")
+
+		switch strings.TrimSpace(key) {
+		case "description":
+			tagged.Description = value
+		case "format":
+			tagged.Format = value
+		case "required":
+			required = value == "" || value == "true"
+		case "enum":
+			enumValue, err := parseEnumValue(value, fieldType)
+			if err != nil {
+				return nil, false, err
+			}
+
+			// Enums on slices constrain the elements
+			if tagged.Type == "array" && tagged.Items != nil {
+				items := *tagged.Items
+				items.Enum = append(items.Enum, enumValue)
+				tagged.Items = &items
+			} else {
+				tagged.Enum = append(tagged.Enum, enumValue)
+			}
+		case "":
+		default:
+			return nil, false, fmt.Errorf("unknown jsonschema option %q", key)
+		}
+	}
+
+	return &tagged, required, nil
+}
+
+// splitEscaped splits s on commas that are not escaped with a backslash
+func splitEscaped(s string) []string {
+	var parts []string
+	var current strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == ',':
+			current.WriteByte(',')
+			i++
+		case s[i] == ',':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(s[i])
+		}
+	}
+
+	return append(parts, current.String())
+}
+
+// parseEnumValue converts an enum tag value to the JSON type of the field
+func parseEnumValue(value string, fieldType reflect.Type) (interface{}, error) {
+	for fieldType.Kind() == reflect.Pointer || fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
+		fieldType = fieldType.Elem()
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer enum value %q", value)
+		}
+		return n, nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number enum value %q", value)
+		}
+		return f, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean enum value %q", value)
+		}
+		return b, nil
+	}
+
+	return value, nil
+}
+
+// Validate checks a decoded JSON value against the schema. Values must be decoded
+// with json.Decoder.UseNumber so that integers can be told apart from other numbers.
+// Null is accepted for properties that are not required, matching how encoding/json
+// leaves such fields unset.
+func (s *Schema) Validate(value interface{}) error {
+	return s.validate("$", value)
+}
+
+func (s *Schema) validate(path string, value interface{}) error {
+	if s == nil {
+		return nil
+	}
+
+	if len(s.AnyOf) > 0 {
+		var errs []error
+		for _, option := range s.AnyOf {
+			err := option.validate(path, value)
+			if err == nil {
+				return nil
+			}
+			// Nullable schemas report why the value failed the non-null option
+			if option.Type != "null" {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) == 1 {
+			return errs[0]
+		}
+		return fmt.Errorf("%s: does not match any allowed schema: %w", path, errors.Join(errs...))
+	}
+
+	if s.Type != "" {
+		if actual := jsonType(value); actual != s.Type && !(s.Type == "number" && actual == "integer") {
+			return fmt.Errorf("%s: expected %s, got %s", path, s.Type, actual)
+		}
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		return fmt.Errorf("%s: value %v is not one of %v", path, value, s.Enum)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+
+		required := make(map[string]bool, len(s.Required))
+		for _, name := range s.Required {
+			required[name] = true
+		}
+
+		for name, property := range v {
+			propertyPath := path + "." + name
+			propertySchema, ok := s.Properties[name]
+			if !ok {
+				switch additional := s.AdditionalProperties.(type) {
+				case *Schema:
+					propertySchema = additional
+				case bool:
+					if !additional {
+						return fmt.Errorf("%s: unexpected property", propertyPath)
+					}
+				}
+			}
+
+			if property == nil && !required[name] {
+				continue
+			}
+			if err := propertySchema.validate(propertyPath, property); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		for i, item := range v {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// jsonType returns the JSON Schema type of a decoded JSON value
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// inEnum reports whether value is one of the enum values
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+package ai
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type schemaAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city,omitempty"`
+}
+
+type schemaBase struct {
+	ID      string `json:"id"`
+	Comment string `json:"comment"`
+}
+
+type schemaLevel int
+
+func (schemaLevel) JSONSchema() *Schema {
+	return &Schema{Type: "integer", Enum: []interface{}{1, 2, 3}}
+}
+
+type schemaPerson struct {
+	schemaBase
+	Name      string             `json:"name" jsonschema:"description=The person's full name\\, including titles"`
+	Age       int                `json:"age,omitempty" jsonschema:"required"`
+	Mood      string             `json:"mood" jsonschema:"enum=happy,enum=sad"`
+	Tags      []string           `json:"tags" jsonschema:"enum=a,enum=b"`
+	Scores    map[string]float64 `json:"scores"`
+	Address   *schemaAddress     `json:"address,omitempty"`
+	Born      time.Time          `json:"born"`
+	Level     schemaLevel        `json:"level"`
+	Extra     json.RawMessage    `json:"extra"`
+	Count     int64              `json:"count,string"`
+	Comment   string             `json:"comment_override"`
+	Ignored   string             `json:"-"`
+	unexposed string
+}
+
+type schemaNode struct {
+	Children []schemaNode `json:"children"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema, err := SchemaFor(&schemaPerson{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if schema.Type != "object" {
+		t.Errorf("Expected object schema, got %s", schema.Type)
+	}
+
+	expected := map[string]*Schema{
+		"id":               {Type: "string"},
+		"comment":          {Type: "string"},
+		"name":             {Type: "string", Description: "The person's full name, including titles"},
+		"age":              {Type: "integer"},
+		"mood":             {Type: "string", Enum: []interface{}{"happy", "sad"}},
+		"tags":             {AnyOf: []*Schema{{Type: "array", Items: &Schema{Type: "string", Enum: []interface{}{"a", "b"}}}, {Type: "null"}}},
+		"scores":           {AnyOf: []*Schema{{Type: "object", AdditionalProperties: &Schema{Type: "number"}}, {Type: "null"}}},
+		"born":             {Type: "string", Format: "date-time"},
+		"level":            {Type: "integer", Enum: []interface{}{1, 2, 3}},
+		"extra":            {},
+		"count":            {Type: "string"},
+		"comment_override": {Type: "string"},
+	}
+
+	for name, want := range expected {
+		got, ok := schema.Properties[name]
+		if !ok {
+			t.Errorf("Missing property %s", name)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Property %s = %+v, want %+v", name, got, want)
+		}
+	}
+
+	address := schema.Properties["address"]
+	if address == nil || address.Type != "object" || !reflect.DeepEqual(address.Required, []string{"street"}) {
+		t.Errorf("Unexpected address schema: %+v", address)
+	}
+
+	for _, name := range []string{"Ignored", "unexposed", "schemaBase"} {
+		if _, ok := schema.Properties[name]; ok {
+			t.Errorf("Expected property %s to be skipped", name)
+		}
+	}
+
+	required := strings.Join(schema.Required, ",")
+	if required != "name,age,mood,tags,scores,born,level,extra,count,comment_override,id,comment" {
+		t.Errorf("Unexpected required properties: %s", required)
+	}
+}
+
+func TestSchemaForScalars(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected *Schema
+	}{
+		{true, &Schema{Type: "boolean"}},
+		{uint8(1), &Schema{Type: "integer"}},
+		{1.5, &Schema{Type: "number"}},
+		{"text", &Schema{Type: "string"}},
+		{[]byte("data"), &Schema{Type: "string", Format: "byte"}},
+		{[]int{1}, &Schema{Type: "array", Items: &Schema{Type: "integer"}}},
+		{map[int]bool{}, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "boolean"}}},
+		{nil, &Schema{}},
+	}
+
+	for _, tt := range tests {
+		schema, err := SchemaFor(tt.value)
+		if err != nil {
+			t.Errorf("SchemaFor(%T) error = %v", tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(schema, tt.expected) {
+			t.Errorf("SchemaFor(%T) = %+v, want %+v", tt.value, schema, tt.expected)
+		}
+	}
+}
+
+func TestSchemaForRecursive(t *testing.T) {
+	schema, err := SchemaFor(&schemaNode{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The repeated node type accepts any value
+	expected := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"children": {AnyOf: []*Schema{{Type: "array", Items: &Schema{}}, {Type: "null"}}},
+		},
+		Required: []string{"children"},
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("Unexpected schema: %+v", schema)
+	}
+
+	var tree interface{}
+	json.Unmarshal([]byte(`{"children": [{"children": [{"children": []}]}]}`), &tree)
+	if err := schema.Validate(tree); err != nil {
+		t.Errorf("Expected a tree to be valid, got %v", err)
+	}
+}
+
+func TestSchemaForNilValues(t *testing.T) {
+	type nilValues struct {
+		Pointer   *int           `json:"pointer"`
+		Slice     []string       `json:"slice"`
+		Map       map[string]int `json:"map"`
+		Interface interface{}    `json:"interface"`
+		Optional  *int           `json:"optional,omitempty"`
+	}
+
+	schema, err := SchemaFor(nilValues{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]*Schema{
+		"pointer":   {AnyOf: []*Schema{{Type: "integer"}, {Type: "null"}}},
+		"slice":     {AnyOf: []*Schema{{Type: "array", Items: &Schema{Type: "string"}}, {Type: "null"}}},
+		"map":       {AnyOf: []*Schema{{Type: "object", AdditionalProperties: &Schema{Type: "integer"}}, {Type: "null"}}},
+		"interface": {},
+		"optional":  {Type: "integer"},
+	}
+	for name, want := range expected {
+		if got := schema.Properties[name]; !reflect.DeepEqual(got, want) {
+			t.Errorf("Property %s = %+v, want %+v", name, got, want)
+		}
+	}
+
+	// The zero value encodes every field without omitempty as null
+	data, _ := json.Marshal(nilValues{})
+	var value interface{}
+	json.Unmarshal(data, &value)
+	if err := schema.Validate(value); err != nil {
+		t.Errorf("Expected %s to be valid, got %v", data, err)
+	}
+}
+
+func TestSchemaForErrors(t *testing.T) {
+	if _, err := SchemaFor(make(chan int)); err == nil {
+		t.Errorf("Expected error for channel type")
+	}
+
+	type badEnum struct {
+		Level int `json:"level" jsonschema:"enum=high"`
+	}
+	if _, err := SchemaFor(badEnum{}); err == nil {
+		t.Errorf("Expected error for invalid enum value")
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := SchemaFor(&schemaPerson{})
+	if err != nil {
+		t.Fatalf("failed to generate schema: %v", err)
+	}
+
+	valid := `{"id":"1","comment":"","name":"Ada","age":36,"mood":"happy","tags":["a"],"scores":{"math":9.5},
+		"address":null,"born":"1815-12-10T00:00:00Z","level":2,"extra":{"any":[1,"two"]},"count":"7","comment_override":""}`
+
+	tests := []struct {
+		name        string
+		patch       func(map[string]interface{})
+		expectError string
+	}{
+		{"valid", func(map[string]interface{}) {}, ""},
+		{"missing required", func(v map[string]interface{}) { delete(v, "name") }, `$: missing required property "name"`},
+		{"wrong type", func(v map[string]interface{}) { v["age"] = "old" }, "$.age: expected integer, got string"},
+		{"fractional integer", func(v map[string]interface{}) { v["age"] = json.Number("36.5") }, "$.age: expected integer, got number"},
+		{"enum", func(v map[string]interface{}) { v["mood"] = "angry" }, "$.mood: value angry is not one of [happy sad]"},
+		{"array item enum", func(v map[string]interface{}) { v["tags"] = []interface{}{"a", "c"} }, "$.tags[1]: value c is not one of [a b]"},
+		{"map value", func(v map[string]interface{}) { v["scores"] = map[string]interface{}{"math": "A"} }, "$.scores.math: expected number, got string"},
+		{"nested required", func(v map[string]interface{}) { v["address"] = map[string]interface{}{} }, `$.address: missing required property "street"`},
+		{"null required", func(v map[string]interface{}) { v["name"] = nil }, "$.name: expected string, got null"},
+		{"null slice", func(v map[string]interface{}) { v["tags"] = nil }, ""},
+		{"null map", func(v map[string]interface{}) { v["scores"] = nil }, ""},
+		{"null raw message", func(v map[string]interface{}) { v["extra"] = nil }, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := json.NewDecoder(strings.NewReader(valid))
+			decoder.UseNumber()
+
+			var value map[string]interface{}
+			if err := decoder.Decode(&value); err != nil {
+				t.Fatalf("failed to decode value: %v", err)
+			}
+			tt.patch(value)
+
+			err := schema.Validate(value)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectError {
+				t.Errorf("Expected error %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestSchemaValidateAnyOf(t *testing.T) {
+	schema := &Schema{AnyOf: []*Schema{{Type: "string"}, {Type: "null"}}}
+
+	if err := schema.Validate("text"); err != nil {
+		t.Errorf("Expected string to be valid, got %v", err)
+	}
+	if err := schema.Validate(nil); err != nil {
+		t.Errorf("Expected null to be valid, got %v", err)
+	}
+	if err := schema.Validate(true); err == nil {
+		t.Errorf("Expected boolean to be invalid")
+	}
+}
@@ -0,0 +1,245 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+)
+
+// ErrStreamingNotSupported is returned when streaming is requested from a provider that cannot stream
+var ErrStreamingNotSupported = errors.New("provider does not support streaming")
+
+// StreamingProvider is implemented by providers that can stream responses as they are generated
+type StreamingProvider interface {
+	StreamText(ctx context.Context, config *Config) (*Stream, error)
+}
+
+// Delta is an incremental piece of a streamed response
+type Delta struct {
+	// Text is the next fragment of the response text
+	Text string
+
+	// ToolCall is the next fragment of a tool call, if any
+	ToolCall *ToolCallDelta
+
+	// Response metadata, set on the deltas where the provider reports it
+	// and aggregated into the stream's Result. Zero usage counts are ignored.
+	FinishReason FinishReason
+	Usage        *Usage
+	ID           string
+	Model        string
+}
+
+// ToolCallDelta is a fragment of a streamed tool call. Fragments with the same
+// Index belong to the same call; ID and Name are set on the first fragment.
+type ToolCallDelta struct {
+	Index     int
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// StreamReader is implemented by providers to read deltas from a response stream
+type StreamReader interface {
+	// Recv returns the next delta, or io.EOF once the stream has finished
+	Recv() (Delta, error)
+
+	// Close releases the underlying connection
+	Close() error
+}
+
+// Stream is a streamed response. Call Next to advance through the deltas,
+// then Err to check for failures and Result for the aggregated response.
+//
+//	stream, err := client.StreamText(ctx, ai.WithMessages(...))
+//	if err != nil { ... }
+//	defer stream.Close()
+//	for stream.Next() {
+//		fmt.Print(stream.Delta().Text)
+//	}
+//	if err := stream.Err(); err != nil { ... }
+type Stream struct {
+	reader StreamReader
+	delta  Delta
+	err    error
+	done   bool
+
+	text      []byte
+	toolCalls map[int]*ToolCall
+	arguments map[int][]byte
+	metadata  Result
+
+	onFinish []func(*Result, error)
+
+	// closeErr is returned by Close, for failures handling the finished stream
+	closeErr error
+}
+
+// NewStream creates a Stream that reads deltas from the given reader
+func NewStream(reader StreamReader) *Stream {
+	return &Stream{
+		reader:    reader,
+		toolCalls: make(map[int]*ToolCall),
+		arguments: make(map[int][]byte),
+	}
+}
+
+// Next advances to the next delta, returning false when the stream ends or fails.
+// Deltas that only carry response metadata are folded into Result and skipped.
+func (s *Stream) Next() bool {
+	for !s.done {
+		delta, err := s.reader.Recv()
+		if err != nil {
+			s.done = true
+			if err != io.EOF {
+				s.err = err
+			}
+			s.reader.Close()
+			s.finish()
+			return false
+		}
+
+		s.aggregate(delta)
+
+		if delta.Text != "" || delta.ToolCall != nil {
+			s.delta = delta
+			return true
+		}
+	}
+
+	return false
+}
+
+// aggregate adds a delta to the aggregated result
+func (s *Stream) aggregate(delta Delta) {
+	s.text = append(s.text, delta.Text...)
+
+	if call := delta.ToolCall; call != nil {
+		aggregated, ok := s.toolCalls[call.Index]
+		if !ok {
+			aggregated = &ToolCall{}
+			s.toolCalls[call.Index] = aggregated
+		}
+		if call.ID != "" {
+			aggregated.ID = call.ID
+		}
+		if call.Name != "" {
+			aggregated.Name = call.Name
+		}
+		s.arguments[call.Index] = append(s.arguments[call.Index], call.Arguments...)
+	}
+
+	if delta.FinishReason != "" {
+		s.metadata.FinishReason = delta.FinishReason
+	}
+	if delta.Usage != nil {
+		if delta.Usage.InputTokens != 0 {
+			s.metadata.Usage.InputTokens = delta.Usage.InputTokens
+		}
+		if delta.Usage.OutputTokens != 0 {
+			s.metadata.Usage.OutputTokens = delta.Usage.OutputTokens
+		}
+		if delta.Usage.CachedInputTokens != 0 {
+			s.metadata.Usage.CachedInputTokens = delta.Usage.CachedInputTokens
+		}
+	}
+	if delta.ID != "" {
+		s.metadata.ID = delta.ID
+	}
+	if delta.Model != "" {
+		s.metadata.Model = delta.Model
+	}
+}
+
+// Delta returns the current delta
+func (s *Stream) Delta() Delta {
+	return s.delta
+}
+
+// Err returns the error that ended the stream, if any
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// Result returns the response aggregated from the deltas received so far.
+// It is complete once Next has returned false and Err is nil.
+func (s *Stream) Result() *Result {
+	result := &Result{
+		Text:         string(s.text),
+		FinishReason: s.metadata.FinishReason,
+		Usage:        s.metadata.Usage,
+		ID:           s.metadata.ID,
+		Model:        s.metadata.Model,
+		Provider:     s.metadata.Provider,
+		Cached:       s.metadata.Cached,
+		Cost:         s.metadata.Cost,
+	}
+
+	indexes := make([]int, 0, len(s.toolCalls))
+	for index := range s.toolCalls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		call := *s.toolCalls[index]
+		call.Arguments = json.RawMessage(s.arguments[index])
+		if len(call.Arguments) == 0 {
+			call.Arguments = json.RawMessage(`{}`)
+		}
+		result.ToolCalls = append(result.ToolCalls, call)
+	}
+
+	return result
+}
+
+// Close stops the stream and releases the underlying connection. It also reports
+// failures handling the finished stream, such as saving it to a Conversation.
+func (s *Stream) Close() error {
+	if s.done {
+		return s.closeErr
+	}
+	s.done = true
+	err := s.reader.Close()
+	s.finish()
+	if err != nil {
+		return err
+	}
+	return s.closeErr
+}
+
+// OnFinish registers a function called once the stream ends with its result and any error.
+// If the stream is closed before it ends, the result holds what was received and the error is nil.
+func (s *Stream) OnFinish(fn func(result *Result, err error)) {
+	s.onFinish = append(s.onFinish, fn)
+}
+
+// finish calls the functions registered with OnFinish
+func (s *Stream) finish() {
+	if len(s.onFinish) == 0 {
+		return
+	}
+	result := s.Result()
+	for _, fn := range s.onFinish {
+		fn(result, s.err)
+	}
+	s.onFinish = nil
+}
+
+// StreamText streams a response from the specified provider
+func (c *Client) StreamText(ctx context.Context, options ...Option) (*Stream, error) {
+	config := c.mergeConfig(options...)
+
+	if _, err := c.provider(config); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.handle(ctx, &Request{Kind: RequestStream, Config: config})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Stream, nil
+}
@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// sliceReader implements StreamReader over a fixed list of deltas
+type sliceReader struct {
+	deltas []Delta
+	err    error
+	closed bool
+}
+
+func (r *sliceReader) Recv() (Delta, error) {
+	if len(r.deltas) == 0 {
+		if r.err != nil {
+			return Delta{}, r.err
+		}
+		return Delta{}, io.EOF
+	}
+	delta := r.deltas[0]
+	r.deltas = r.deltas[1:]
+	return delta, nil
+}
+
+func (r *sliceReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+// MockStreamer implements LLMProvider and StreamingProvider for testing
+type MockStreamer struct {
+	MockProvider
+	StreamTextFunc func(ctx context.Context, config *Config) (*Stream, error)
+}
+
+func (m *MockStreamer) StreamText(ctx context.Context, config *Config) (*Stream, error) {
+	return m.StreamTextFunc(ctx, config)
+}
+
+func TestStream(t *testing.T) {
+	reader := &sliceReader{
+		deltas: []Delta{
+			{ID: "resp_1", Model: "test-model", Usage: &Usage{InputTokens: 20}},
+			{Text: "Checking "},
+			{Text: "the weather."},
+			{ToolCall: &ToolCallDelta{Index: 1, ID: "call_2", Name: "get_time"}},
+			{ToolCall: &ToolCallDelta{Index: 0, ID: "call_1", Name: "get_weather"}},
+			{ToolCall: &ToolCallDelta{Index: 0, Arguments: `{"city":`}},
+			{ToolCall: &ToolCallDelta{Index: 0, Arguments: `"Paris"}`}},
+			{FinishReason: FinishReasonToolCalls, Usage: &Usage{OutputTokens: 15}},
+		},
+	}
+
+	stream := NewStream(reader)
+
+	count := 0
+	for stream.Next() {
+		count++
+	}
+
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Metadata-only deltas are not returned by Next
+	if count != 6 {
+		t.Errorf("Expected 6 deltas, got %d", count)
+	}
+	if !reader.closed {
+		t.Errorf("Expected reader to be closed at the end of the stream")
+	}
+
+	result := stream.Result()
+	if result.Text != "Checking the weather." {
+		t.Errorf("Unexpected text: %s", result.Text)
+	}
+	if result.ID != "resp_1" || result.Model != "test-model" || result.FinishReason != FinishReasonToolCalls {
+		t.Errorf("Unexpected metadata: %+v", result)
+	}
+	if result.Usage.InputTokens != 20 || result.Usage.OutputTokens != 15 {
+		t.Errorf("Unexpected usage: %+v", result.Usage)
+	}
+
+	if len(result.ToolCalls) != 2 {
+		t.Fatalf("Expected 2 tool calls, got %d", len(result.ToolCalls))
+	}
+	if result.ToolCalls[0].ID != "call_1" || string(result.ToolCalls[0].Arguments) != `{"city":"Paris"}` {
+		t.Errorf("Unexpected first tool call: %+v", result.ToolCalls[0])
+	}
+	if result.ToolCalls[1].Name != "get_time" || string(result.ToolCalls[1].Arguments) != `{}` {
+		t.Errorf("Unexpected second tool call: %+v", result.ToolCalls[1])
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	failure := errors.New("connection reset")
+	stream := NewStream(&sliceReader{
+		deltas: []Delta{{Text: "Hello"}},
+		err:    failure,
+	})
+
+	for stream.Next() {
+	}
+
+	if !errors.Is(stream.Err(), failure) {
+		t.Errorf("Expected stream error, got %v", stream.Err())
+	}
+	if stream.Result().Text != "Hello" {
+		t.Errorf("Expected partial text 'Hello', got %s", stream.Result().Text)
+	}
+}
+
+func TestClientStreamText(t *testing.T) {
+	mockStreamer := &MockStreamer{
+		StreamTextFunc: func(ctx context.Context, config *Config) (*Stream, error) {
+			return NewStream(&sliceReader{deltas: []Delta{{Text: "Hello, world!"}}}), nil
+		},
+	}
+
+	client := NewClient(WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, mockStreamer)
+	client.RegisterProvider(ProviderAnthropic, &MockProvider{})
+
+	stream, err := client.StreamText(context.Background(), WithProvider(ProviderOpenAI))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for stream.Next() {
+	}
+	if stream.Result().Text != "Hello, world!" {
+		t.Errorf("Expected 'Hello, world!', got %s", stream.Result().Text)
+	}
+
+	_, err = client.StreamText(context.Background(), WithProvider(ProviderAnthropic))
+	if !errors.Is(err, ErrStreamingNotSupported) {
+		t.Errorf("Expected ErrStreamingNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package ai
+
+import "context"
+
+// TokenCounter is implemented by providers that can count the input tokens of a request
+// exactly, with a local tokenizer or the provider's API
+type TokenCounter interface {
+	CountTokens(ctx context.Context, config *Config) (int, error)
+}
+
+// CountTokens returns the number of input tokens the request's messages and tools would use,
+// so prompts can be checked against a model's context window before they are sent. Providers
+// that implement TokenCounter count them exactly; for the rest they are estimated with EstimateTokens.
+func (c *Client) CountTokens(ctx context.Context, options ...Option) (int, error) {
+	config := c.mergeConfig(options...)
+
+	provider, err := c.provider(config)
+	if err != nil {
+		return 0, err
+	}
+
+	return countTokens(ctx, provider, config)
+}
+
+// countTokens counts the input tokens of a request with the provider if it can, or estimates them
+func countTokens(ctx context.Context, provider LLMProvider, config *Config) (int, error) {
+	if counter, ok := provider.(TokenCounter); ok {
+		return counter.CountTokens(ctx, config)
+	}
+	return EstimateTokens(config), nil
+}
+
+// EstimateTokens quickly estimates the input tokens of a request without a tokenizer,
+// counting four characters of text per token and a few tokens of overhead per message.
+// Images and documents are not counted.
+func EstimateTokens(config *Config) int {
+	chars := 0
+	for _, msg := range config.Messages {
+		chars += len(msg.Content)
+		for _, part := range msg.Parts {
+			chars += len(part.Text)
+		}
+		for _, call := range msg.ToolCalls {
+			chars += len(call.Name) + len(call.Arguments)
+		}
+	}
+	for _, tool := range config.Tools {
+		chars += len(tool.Name) + len(tool.Description) + len(tool.Parameters)
+	}
+
+	return chars/4 + 4*len(config.Messages)
+}
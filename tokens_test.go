@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// MockTokenCounter implements LLMProvider and TokenCounter for testing
+type MockTokenCounter struct {
+	MockProvider
+	CountTokensFunc func(ctx context.Context, config *Config) (int, error)
+}
+
+func (m *MockTokenCounter) CountTokens(ctx context.Context, config *Config) (int, error) {
+	return m.CountTokensFunc(ctx, config)
+}
+
+func TestEstimateTokens(t *testing.T) {
+	config := &Config{
+		Messages: []Message{
+			SystemMessage(strings.Repeat("a", 40)),
+			UserMessageParts(TextPart(strings.Repeat("b", 20)), ImagePart([]byte("png"), "image/png")),
+		},
+		Tools: []Tool{{Name: "tool", Description: strings.Repeat("c", 16)}},
+	}
+
+	// 80 characters of text and two messages
+	if got := EstimateTokens(config); got != 20+8 {
+		t.Errorf("Expected 28 tokens, got %d", got)
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	counter := &MockTokenCounter{
+		CountTokensFunc: func(ctx context.Context, config *Config) (int, error) {
+			return 42, nil
+		},
+	}
+
+	client := NewClient(WithProvider(ProviderOpenAI), WithModel("test-model"))
+	client.RegisterProvider(ProviderOpenAI, counter)
+	client.RegisterProvider(ProviderAnthropic, &MockProvider{})
+
+	count, err := client.CountTokens(context.Background(), WithMessages(UserMessage("Hello")))
+	if err != nil || count != 42 {
+		t.Errorf("Expected the provider's count of 42, got %d, %v", count, err)
+	}
+
+	// Providers that cannot count tokens are estimated
+	count, err = client.CountTokens(context.Background(),
+		WithProvider(ProviderAnthropic),
+		WithMessages(UserMessage(strings.Repeat("a", 400))),
+	)
+	if err != nil || count != 104 {
+		t.Errorf("Expected an estimate of 104, got %d, %v", count, err)
+	}
+
+	// Wrapped providers still count with the provider
+	for name, wrapped := range map[string]LLMProvider{
+		"cache":        NewCache(NewMemoryCache(10)).Wrap(counter),
+		"rate limiter": NewRateLimiter().Wrap(counter),
+	} {
+		client.RegisterProvider(ProviderOpenAI, wrapped)
+		if count, err := client.CountTokens(context.Background()); err != nil || count != 42 {
+			t.Errorf("%s: expected the provider's count of 42, got %d, %v", name, count, err)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package ai
 
 import (
 	"context"
+	"encoding/json"
+	"log/slog"
 )
 
 // Provider represents the LLM service provider
@@ -19,12 +21,23 @@ const (
 	RoleSystem    MessageRole = "system"
 	RoleUser      MessageRole = "user"
 	RoleAssistant MessageRole = "assistant"
+	RoleTool      MessageRole = "tool"
 )
 
 // Message represents a single message in a conversation
 type Message struct {
 	Role    MessageRole `json:"role"`
 	Content string      `json:"content"`
+
+	// Parts holds multimodal content such as images and documents.
+	// When set, it is sent instead of Content.
+	Parts []Part `json:"parts,omitempty"`
+
+	// ToolCalls holds the tools the assistant asked to call
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// ToolCallID links a tool message to the call it answers
+	ToolCallID string `json:"tool_call_id,omitempty"`
 }
 
 // SystemMessage creates a new system message
@@ -51,12 +64,114 @@ func AssistantMessage(content string) Message {
 	}
 }
 
+// AssistantToolCallMessage creates a new assistant message that requests tool calls
+func AssistantToolCallMessage(content string, calls ...ToolCall) Message {
+	return Message{
+		Role:      RoleAssistant,
+		Content:   content,
+		ToolCalls: calls,
+	}
+}
+
+// ToolResultMessage creates a new tool message carrying the result of a tool call
+func ToolResultMessage(toolCallID string, content string) Message {
+	return Message{
+		Role:       RoleTool,
+		Content:    content,
+		ToolCallID: toolCallID,
+	}
+}
+
+// Tool describes a function the model may call
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"` // JSON Schema for the arguments
+}
+
+// ToolCall represents a request from the model to call a tool
+type ToolCall struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// FinishReason is the normalized reason a model stopped generating
+type FinishReason string
+
+const (
+	FinishReasonStop          FinishReason = "stop"
+	FinishReasonLength        FinishReason = "length"
+	FinishReasonToolCalls     FinishReason = "tool_calls"
+	FinishReasonContentFilter FinishReason = "content_filter"
+	FinishReasonOther         FinishReason = "other"
+)
+
+// Usage holds the token counts reported for a request
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+
+	// CachedInputTokens is the part of InputTokens read from the provider's prompt cache
+	CachedInputTokens int `json:"cached_input_tokens,omitempty"`
+}
+
+// TotalTokens returns the sum of input and output tokens
+func (u Usage) TotalTokens() int {
+	return u.InputTokens + u.OutputTokens
+}
+
+// Result holds the full response to a request, including any tool calls
+type Result struct {
+	Text      string
+	ToolCalls []ToolCall
+
+	// FinishReason is why the model stopped; FinishReasonLength means the output was truncated
+	FinishReason FinishReason
+
+	// Usage is the token usage reported by the provider
+	Usage Usage
+
+	// ID is the provider's identifier for the response
+	ID string
+
+	// Model is the model that actually served the request
+	Model string
+
+	// Provider is the provider that served the request, set by a Fallback
+	Provider Provider
+
+	// Cached reports whether the result was served from a Cache rather than the provider
+	Cached bool
+
+	// Cost is the price of the request in US dollars, set by a CostTracker
+	Cost float64
+}
+
+// Message returns the result as an assistant message that can be appended to the conversation
+func (r *Result) Message() Message {
+	return AssistantToolCallMessage(r.Text, r.ToolCalls...)
+}
+
 // LLMProvider defines the interface that all LLM providers must implement
 type LLMProvider interface {
 	GetText(ctx context.Context, config *Config) (string, error)
 	GetObject(ctx context.Context, config *Config, target interface{}) error
 }
 
+// Generator is implemented by providers that can return a full Result rather than just text.
+// Providers must implement it to support tool calling.
+type Generator interface {
+	GenerateText(ctx context.Context, config *Config) (*Result, error)
+}
+
+// ObjectGenerator is implemented by providers that can return the response metadata
+// for a structured response. The result's Text is the JSON the object was decoded from.
+// When the response is invalid, the result is returned along with the *InvalidObjectError.
+type ObjectGenerator interface {
+	GenerateObject(ctx context.Context, config *Config, target interface{}) (*Result, error)
+}
+
 // Config holds the configuration for a request to an LLM provider
 type Config struct {
 	Provider    Provider
@@ -64,6 +179,49 @@ type Config struct {
 	Messages    []Message
 	MaxTokens   int
 	Temperature float64
+	Tools       []Tool
+
+	// ToolHandlers maps tool names to the functions Run uses to execute them
+	ToolHandlers map[string]ToolHandler
+
+	// MaxSteps limits the number of model calls Run makes (defaults to DefaultMaxSteps)
+	MaxSteps int
+
+	// ParallelToolCalls makes Run execute the tool calls of a single step concurrently
+	ParallelToolCalls bool
+
+	// ObjectMode selects how providers obtain structured output in GetObject
+	ObjectMode ObjectMode
+
+	// RepairAttempts is the number of times GetObject re-prompts the model after an invalid response
+	RepairAttempts int
+
+	// RepairStrategy builds the messages for a repair attempt (defaults to DefaultRepairStrategy)
+	RepairStrategy RepairStrategy
+
+	// Retry overrides the provider's retry policy when set
+	Retry *RetryPolicy
+
+	// RateLimitObserver is called by providers with the rate limits reported in each response
+	RateLimitObserver func(RateLimitStatus)
+
+	// CacheBypass makes the request skip any Cache
+	CacheBypass bool
+
+	// CostTags label the request in a CostTracker's totals
+	CostTags []string
+
+	// Models is the registry requests are checked against (defaults to DefaultModels)
+	Models *ModelRegistry
+
+	// RejectDeprecated fails requests to models past their deprecation date instead of logging a warning
+	RejectDeprecated bool
+
+	// Logger receives a log of each request, at LogLevel when it succeeds.
+	// LogContent adds the full prompt and response.
+	Logger     *slog.Logger
+	LogLevel   slog.Level
+	LogContent bool
 }
 
 // Option is a function that modifies a Config
@@ -103,3 +261,10 @@ func WithTemperature(temperature float64) Option {
 		c.Temperature = temperature
 	}
 }
+
+// WithTools sets the tools the model may call
+func WithTools(tools ...Tool) Option {
+	return func(c *Config) {
+		c.Tools = tools
+	}
+}